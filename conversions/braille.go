@@ -0,0 +1,132 @@
+package conversions
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Braille cells are Unicode code points from U+2800, with a bit per dot, dot 1 being the lowest bit
+const (
+	brailleBlank = 0x2800
+	brailleCapital = 0x2800 | 0x20
+	brailleNumber = 0x2800 | 0x3c
+	brailleLetter = 0x2800 | 0x30
+)
+
+// The dots of the letters a to j, which are also the digits 1 to 0 after a number sign
+var brailleDecade = []rune{ 0x01, 0x03, 0x09, 0x19, 0x11, 0x0b, 0x1b, 0x13, 0x0a, 0x1a }
+
+var braillePunctuation = map[rune]rune{
+	',': 0x02, ';': 0x06, ':': 0x12, '.': 0x32, '!': 0x16, '?': 0x26, '\'': 0x04, '-': 0x24, '(': 0x36, ')': 0x36,
+}
+
+func brailleLetterCell(letter rune) rune {
+	switch {
+		case letter < 'k': return brailleBlank | brailleDecade[letter - 'a']
+		case letter < 'u': return brailleBlank | brailleDecade[letter - 'k'] | 0x04
+		// "w" was added to the French alphabet after the rest, so it doesn't follow the pattern
+		case letter == 'w': return brailleBlank | 0x3a
+		case letter < 'w': return brailleBlank | brailleDecade[letter - 'u'] | 0x24
+	}
+	return brailleBlank | brailleDecade[letter - 'v'] | 0x24
+}
+
+// Transcribes text in uncontracted (grade 1) English Braille. Capitals are marked with
+// dot 6, numbers with the number sign, and a letter sign separates letters a to j from
+// the digits before them.
+func encodeBraille(input string) (string, error) {
+	var output strings.Builder
+	number := false
+	for _, r := range input {
+		lower := unicode.ToLower(r)
+		switch {
+			case r >= '0' && r <= '9':
+				if !number { output.WriteRune(brailleNumber) }
+				number = true
+				output.WriteRune(brailleBlank | brailleDecade[(r - '0' + 9) % 10])
+				continue
+			case lower >= 'a' && lower <= 'z':
+				if number && lower <= 'j' { output.WriteRune(brailleLetter) }
+				if unicode.IsUpper(r) { output.WriteRune(brailleCapital) }
+				output.WriteRune(brailleLetterCell(lower))
+			case r == ' ' || r == '\n' || r == '\t':
+				output.WriteRune(r)
+			case r >= brailleBlank && r <= brailleBlank + 0xff:
+				output.WriteRune(r)
+			default:
+				cell, ok := braillePunctuation[r]
+				if !ok { return "", errors.New("No grade 1 Braille for character '" + string(r) + "'") }
+				output.WriteRune(brailleBlank | cell)
+		}
+		// The decimal point and comma belong to the number
+		number = number && (r == '.' || r == ',')
+	}
+	return output.String(), nil
+}
+
+func decodeBraille(input string) (string, error) {
+	letters := make(map[rune]rune)
+	for letter := 'a'; letter <= 'z'; letter++ {
+		letters[brailleLetterCell(letter)] = letter
+	}
+	punctuation := make(map[rune]rune)
+	for r, cell := range braillePunctuation {
+		if r != ')' { punctuation[brailleBlank | cell] = r }
+	}
+
+	var output strings.Builder
+	number := false
+	capital := false
+	opened := false
+	runes := []rune(input)
+	for i, r := range runes {
+		switch {
+			case r == brailleNumber:
+				number = true
+				continue
+			case r == brailleLetter:
+				number = false
+				continue
+			case r == brailleCapital:
+				capital = true
+				continue
+		}
+		if number {
+			for digit, cell := range brailleDecade {
+				if r == brailleBlank | cell {
+					output.WriteByte(byte('0' + (digit + 1) % 10))
+					r = 0
+				}
+			}
+			if r == 0 { continue }
+			// A decimal point or comma followed by more digits stays in the number
+			nextIsDigit := i + 1 < len(runes) && letters[runes[i + 1]] != 0 && letters[runes[i + 1]] <= 'j'
+			number = (r == brailleBlank | 0x32 || r == brailleBlank | 0x02) && nextIsDigit
+		}
+		if letter, ok := letters[r]; ok {
+			if capital { letter = unicode.ToUpper(letter) }
+			output.WriteRune(letter)
+		} else if p, ok := punctuation[r]; ok {
+			// Both parentheses are the same cell, so they alternate
+			if p == '(' {
+				if opened { p = ')' }
+				opened = !opened
+			}
+			output.WriteRune(p)
+		} else if r == brailleBlank {
+			output.WriteByte(' ')
+		} else if r == ' ' || r == '\n' || r == '\t' {
+			output.WriteRune(r)
+		} else {
+			return "", errors.New("Unknown Braille cell: '" + string(r) + "'")
+		}
+		capital = false
+	}
+	return output.String(), nil
+}
+
+func addBrailleConversions(output *Conversions) {
+	output.Add(Conversion{ "text", "text", "braille", encodeBraille })
+	output.Add(Conversion{ "text", "braille", "text", decodeBraille })
+}
@@ -0,0 +1,374 @@
+package conversions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// All the calendars are converted through the Julian Day Number, the number of days
+// since 1 January 4713 BC in the proleptic Julian calendar.
+type calendarUnit struct {
+	unit string
+	name string
+	toJdn func(input string) (int, error)
+	fromJdn func(jdn int) (string, error)
+}
+
+// Integer division rounding towards negative infinity
+func floorDiv(a int, b int) int {
+	q := a / b
+	if (a % b != 0) && ((a < 0) != (b < 0)) { q-- }
+	return q
+}
+
+func floorMod(a int, b int) int {
+	return a - floorDiv(a, b) * b
+}
+
+// The days that can be converted, about a million years on either side of the epoch, which
+// keeps the arithmetic of the calendars from overflowing
+const (
+	minCalendarJdn = -400000000
+	maxCalendarJdn = 400000000
+)
+
+func checkJdn(jdn float64, input string) (int, error) {
+	if math.IsNaN(jdn) || jdn < minCalendarJdn || jdn > maxCalendarJdn { return 0, errors.New("Day out of the supported range: \"" + input + "\"") }
+	return int(jdn), nil
+}
+
+var ymdRegexp = regexp.MustCompile(`^(-?\d+)-(\d{1,2})-(\d{1,2})$`)
+
+func parseYmd(input string) (int, int, int, error) {
+	m := ymdRegexp.FindStringSubmatch(strings.TrimSpace(input))
+	if m == nil { return 0, 0, 0, errors.New("Invalid date, expected YYYY-MM-DD: \"" + input + "\"") }
+	y, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	d, _ := strconv.Atoi(m[3])
+	return y, month, d, nil
+}
+
+func formatYmd(y int, m int, d int) string {
+	if y < 0 { return fmt.Sprintf("-%04d-%02d-%02d", -y, m, d) }
+	return fmt.Sprintf("%04d-%02d-%02d", y, m, d)
+}
+
+func checkDay(input string, month int, monthCount int, day int, daysInMonth func() int) error {
+	if month < 1 || month > monthCount || day < 1 || day > daysInMonth() { return errors.New("Date does not exist: \"" + input + "\"") }
+	return nil
+}
+
+// Gregorian calendar, using the algorithms from http://howardhinnant.github.io/date_algorithms.html
+
+func gregorianIsLeap(y int) bool {
+	return y % 4 == 0 && (y % 100 != 0 || y % 400 == 0)
+}
+
+func gregorianDaysInMonth(y int, m int) int {
+	if m == 2 && gregorianIsLeap(y) { return 29 }
+	return []int{ 31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31 }[m - 1]
+}
+
+func gregorianToJdn(y int, m int, d int) int {
+	if m <= 2 { y-- }
+	era := floorDiv(y, 400)
+	yoe := y - era * 400
+	mp := (m + 9) % 12
+	doy := (153 * mp + 2) / 5 + d - 1
+	doe := yoe * 365 + yoe / 4 - yoe / 100 + doy
+	return era * 146097 + doe - 719468 + 2440588
+}
+
+func jdnToGregorian(jdn int) (int, int, int) {
+	z := jdn - 2440588 + 719468
+	era := floorDiv(z, 146097)
+	doe := z - era * 146097
+	yoe := (doe - doe / 1460 + doe / 36524 - doe / 146096) / 365
+	y := yoe + era * 400
+	doy := doe - (365 * yoe + yoe / 4 - yoe / 100)
+	mp := (5 * doy + 2) / 153
+	d := doy - (153 * mp + 2) / 5 + 1
+	m := mp + 3
+	if mp >= 10 { m = mp - 9 }
+	if m <= 2 { y++ }
+	return y, m, d
+}
+
+// Proleptic Julian calendar
+
+func julianToJdn(y int, m int, d int) int {
+	a := floorDiv(14 - m, 12)
+	yy := y + 4800 - a
+	mm := m + 12 * a - 3
+	return d + (153 * mm + 2) / 5 + 365 * yy + floorDiv(yy, 4) - 32083
+}
+
+func jdnToJulian(jdn int) (int, int, int) {
+	c := jdn + 32082
+	d := floorDiv(4 * c + 3, 1461)
+	e := c - floorDiv(1461 * d, 4)
+	m := (5 * e + 2) / 153
+	day := e - (153 * m + 2) / 5 + 1
+	month := m + 3 - 12 * (m / 10)
+	year := d - 4800 + m / 10
+	return year, month, day
+}
+
+// ISO 8601 week and ordinal dates
+
+// Returns the ISO day of the week, from 1 (Monday) to 7 (Sunday)
+func isoWeekday(jdn int) int {
+	return floorMod(jdn, 7) + 1
+}
+
+func isoWeeksInYear(y int) int {
+	// A year has 53 weeks when it starts on a Thursday, or on a Wednesday in leap years
+	start := isoWeekday(gregorianToJdn(y, 1, 1))
+	if start == 4 || (start == 3 && gregorianIsLeap(y)) { return 53 }
+	return 52
+}
+
+var isoWeekRegexp = regexp.MustCompile(`^(-?\d{4,})-?W(\d{2})(?:-?(\d))?$`)
+
+func isoWeekToJdn(input string) (int, error) {
+	m := isoWeekRegexp.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(input)))
+	if m == nil { return 0, errors.New("Invalid ISO week date, expected YYYY-Www-D: \"" + input + "\"") }
+	y, _ := strconv.Atoi(m[1])
+	week, _ := strconv.Atoi(m[2])
+	day := 1
+	if m[3] != "" { day, _ = strconv.Atoi(m[3]) }
+	if week < 1 || week > isoWeeksInYear(y) || day < 1 || day > 7 { return 0, errors.New("Date does not exist: \"" + input + "\"") }
+	jan4 := gregorianToJdn(y, 1, 4)
+	return jan4 - isoWeekday(jan4) + 1 + (week - 1) * 7 + day - 1, nil
+}
+
+func jdnToIsoWeek(jdn int) string {
+	weekday := isoWeekday(jdn)
+	// The year of a week is the year of its Thursday
+	thursday := jdn - weekday + 4
+	y, _, _ := jdnToGregorian(thursday)
+	week := (thursday - gregorianToJdn(y, 1, 1)) / 7 + 1
+	if y < 0 { return fmt.Sprintf("-%04d-W%02d-%d", -y, week, weekday) }
+	return fmt.Sprintf("%04d-W%02d-%d", y, week, weekday)
+}
+
+var ordinalRegexp = regexp.MustCompile(`^(-?\d{4,})-?(\d{3})$`)
+
+func ordinalToJdn(input string) (int, error) {
+	m := ordinalRegexp.FindStringSubmatch(strings.TrimSpace(input))
+	if m == nil { return 0, errors.New("Invalid ordinal date, expected YYYY-DDD: \"" + input + "\"") }
+	y, _ := strconv.Atoi(m[1])
+	doy, _ := strconv.Atoi(m[2])
+	daysInYear := 365
+	if gregorianIsLeap(y) { daysInYear = 366 }
+	if doy < 1 || doy > daysInYear { return 0, errors.New("Date does not exist: \"" + input + "\"") }
+	return gregorianToJdn(y, 1, 1) + doy - 1, nil
+}
+
+func jdnToOrdinal(jdn int) string {
+	y, _, _ := jdnToGregorian(jdn)
+	doy := jdn - gregorianToJdn(y, 1, 1) + 1
+	if y < 0 { return fmt.Sprintf("-%04d-%03d", -y, doy) }
+	return fmt.Sprintf("%04d-%03d", y, doy)
+}
+
+// Tabular Islamic calendar (civil epoch, 16 July 622 Julian), as described in
+// "Calendrical Calculations" by Dershowitz and Reingold.
+
+const islamicEpochJdn = 1948440
+
+func islamicIsLeap(y int) bool {
+	return floorMod(14 + 11 * y, 30) < 11
+}
+
+func islamicDaysInMonth(y int, m int) int {
+	if m == 12 && islamicIsLeap(y) { return 30 }
+	if m % 2 == 1 { return 30 }
+	return 29
+}
+
+func islamicToJdn(y int, m int, d int) int {
+	return d + int(math.Ceil(29.5 * float64(m - 1))) + (y - 1) * 354 + floorDiv(3 + 11 * y, 30) + islamicEpochJdn - 1
+}
+
+func jdnToIslamic(jdn int) (int, int, int) {
+	y := floorDiv(30 * (jdn - islamicEpochJdn) + 10646, 10631)
+	m := int(math.Ceil(float64(jdn - 29 - islamicToJdn(y, 1, 1)) / 29.5)) + 1
+	if m > 12 { m = 12 }
+	if m < 1 { m = 1 }
+	d := jdn - islamicToJdn(y, m, 1) + 1
+	return y, m, d
+}
+
+// Hebrew calendar, also from "Calendrical Calculations". Months are numbered from
+// Nisan (1) so that Tishri, the first month of the civil year, is 7 and Adar II, in
+// leap years, is 13.
+
+const hebrewEpochJdn = 347996
+
+func hebrewIsLeap(y int) bool {
+	return floorMod(7 * y + 1, 19) < 7
+}
+
+func hebrewMonthsInYear(y int) int {
+	if hebrewIsLeap(y) { return 13 }
+	return 12
+}
+
+// Number of days from the epoch to the new year, delayed so that it doesn't fall on
+// a Sunday, Wednesday or Friday.
+func hebrewCalendarElapsedDays(y int) int {
+	months := floorDiv(235 * y - 234, 19)
+	parts := 12084 + 13753 * months
+	day := months * 29 + floorDiv(parts, 25920)
+	if floorMod(3 * (day + 1), 7) < 3 { day++ }
+	return day
+}
+
+// Further delays so that years have a valid length
+func hebrewNewYearDelay(y int) int {
+	last := hebrewCalendarElapsedDays(y - 1)
+	present := hebrewCalendarElapsedDays(y)
+	next := hebrewCalendarElapsedDays(y + 1)
+	if next - present == 356 { return 2 }
+	if present - last == 382 { return 1 }
+	return 0
+}
+
+func hebrewDaysInYear(y int) int {
+	return hebrewToJdn(y + 1, 7, 1) - hebrewToJdn(y, 7, 1)
+}
+
+func hebrewDaysInMonth(y int, m int) int {
+	if m == 2 || m == 4 || m == 6 || m == 10 || m == 13 { return 29 }
+	if m == 12 && !hebrewIsLeap(y) { return 29 }
+	if m == 8 && hebrewDaysInYear(y) % 10 != 5 { return 29 }
+	if m == 9 && hebrewDaysInYear(y) % 10 == 3 { return 29 }
+	return 30
+}
+
+func hebrewToJdn(y int, m int, d int) int {
+	jdn := hebrewEpochJdn + hebrewCalendarElapsedDays(y) + hebrewNewYearDelay(y) + d + 1
+	if m < 7 {
+		for i := 7; i <= hebrewMonthsInYear(y); i++ {
+			jdn += hebrewDaysInMonth(y, i)
+		}
+		for i := 1; i < m; i++ {
+			jdn += hebrewDaysInMonth(y, i)
+		}
+	} else {
+		for i := 7; i < m; i++ {
+			jdn += hebrewDaysInMonth(y, i)
+		}
+	}
+	return jdn
+}
+
+func jdnToHebrew(jdn int) (int, int, int) {
+	y := floorDiv((jdn - hebrewEpochJdn) * 98496, 35975351) - 1
+	for jdn >= hebrewToJdn(y + 1, 7, 1) {
+		y++
+	}
+	m := 1
+	if jdn < hebrewToJdn(y, 1, 1) { m = 7 }
+	for jdn > hebrewToJdn(y, m, hebrewDaysInMonth(y, m)) {
+		m++
+	}
+	return y, m, jdn - hebrewToJdn(y, m, 1) + 1
+}
+
+func ymdCalendarUnit(unit string, name string, monthsInYear func(y int) int, daysInMonth func(y int, m int) int, toJdn func(y int, m int, d int) int, fromJdn func(jdn int) (int, int, int)) calendarUnit {
+	return calendarUnit{
+		unit, name,
+		func(input string) (int, error) {
+			y, m, d, err := parseYmd(input)
+			if err != nil { return 0, err }
+			err = checkDay(input, m, monthsInYear(y), d, func() int { return daysInMonth(y, m) })
+			if err != nil { return 0, err }
+			return toJdn(y, m, d), nil
+		},
+		func(jdn int) (string, error) {
+			return formatYmd(fromJdn(jdn)), nil
+		},
+	}
+}
+
+var calendarUnits = []calendarUnit{
+	ymdCalendarUnit("gregorian", "Gregorian Date (YYYY-MM-DD)", func(y int) int { return 12 }, gregorianDaysInMonth, gregorianToJdn, jdnToGregorian),
+	ymdCalendarUnit("julian", "Proleptic Julian Calendar Date (YYYY-MM-DD)", func(y int) int { return 12 }, func(y int, m int) int {
+		if m == 2 && floorMod(y, 4) == 0 { return 29 }
+		return gregorianDaysInMonth(1, m)
+	}, julianToJdn, jdnToJulian),
+	calendarUnit{
+		"jd", "Julian Day Number",
+		func(input string) (int, error) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+			if err != nil { return 0, err }
+			// A Julian day starts at noon
+			return checkJdn(math.Floor(n + 0.5), input)
+		},
+		func(jdn int) (string, error) {
+			return strconv.Itoa(jdn), nil
+		},
+	},
+	calendarUnit{
+		"mjd", "Modified Julian Day",
+		func(input string) (int, error) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+			if err != nil { return 0, err }
+			return checkJdn(math.Floor(n) + 2400001, input)
+		},
+		func(jdn int) (string, error) {
+			return strconv.Itoa(jdn - 2400001), nil
+		},
+	},
+	calendarUnit{
+		"isoweek", "ISO 8601 Week Date (YYYY-Www-D)",
+		isoWeekToJdn,
+		func(jdn int) (string, error) {
+			return jdnToIsoWeek(jdn), nil
+		},
+	},
+	calendarUnit{
+		"ordinal", "Ordinal Date (YYYY-DDD)",
+		ordinalToJdn,
+		func(jdn int) (string, error) {
+			return jdnToOrdinal(jdn), nil
+		},
+	},
+	ymdCalendarUnit("hijri", "Tabular Islamic Calendar Date (YYYY-MM-DD)", func(y int) int { return 12 }, islamicDaysInMonth, islamicToJdn, jdnToIslamic),
+	ymdCalendarUnit("hebrew", "Hebrew Calendar Date (YYYY-MM-DD, months counted from Nisan)", hebrewMonthsInYear, hebrewDaysInMonth, hebrewToJdn, jdnToHebrew),
+}
+
+func findCalendarUnit(unit string) (calendarUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range calendarUnits {
+		if u.unit == unit { return u, true }
+	}
+	return calendarUnit{}, false
+}
+
+func addCalendarConversions(output *Conversions) {
+	calendarConv := func(input string, from calendarUnit, to calendarUnit) (string, error) {
+		jdn, err := from.toJdn(input)
+		if err != nil { return "", err }
+		if _, err := checkJdn(float64(jdn), input); err != nil { return "", err }
+		return to.fromJdn(jdn)
+	}
+
+	for _, from := range calendarUnits {
+		for _, to := range calendarUnits {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"calendar", from.unit, to.unit, func(input string) (string, error) {
+					return calendarConv(input, from, to)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package conversions
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Splits an identifier into lowercase words, at separators and at changes of case.
+// A run of capitals is an acronym, so "HTTPServer" is split into "http" and "server".
+func identifierWords(input string) []string {
+	var words []string
+	for _, token := range strings.FieldsFunc(input, func(c rune) bool { return !unicode.IsLetter(c) && !unicode.IsDigit(c) }) {
+		runes := []rune(token)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			previous := runes[i - 1]
+			current := runes[i]
+			boundary := false
+			switch {
+				// "fooBar" and "v2Api"
+				case unicode.IsUpper(current) && (unicode.IsLower(previous) || unicode.IsDigit(previous)):
+					boundary = true
+				// The last capital of an acronym starts the next word, eg. "HTTPServer", unless
+				// it is followed by a single lowercase letter that ends the token or precedes a
+				// digit, as in "IDs" or "IPv6"
+				case unicode.IsUpper(previous) && unicode.IsUpper(current) && i + 1 < len(runes) && unicode.IsLower(runes[i + 1]):
+					boundary = i + 2 < len(runes) && !unicode.IsDigit(runes[i + 2])
+			}
+			if boundary {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+func capitalize(word string) string {
+	runes := []rune(word)
+	if len(runes) == 0 { return word }
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+type caseStyle struct {
+	unit string
+	name string
+	format func(words []string) string
+}
+
+var caseStyles = []caseStyle{
+	{
+		"camel", "camelCase",
+		func(words []string) string {
+			output := ""
+			for i, word := range words {
+				if i == 0 {
+					output += word
+				} else {
+					output += capitalize(word)
+				}
+			}
+			return output
+		},
+	},
+	{
+		"pascal", "PascalCase",
+		func(words []string) string {
+			output := ""
+			for _, word := range words {
+				output += capitalize(word)
+			}
+			return output
+		},
+	},
+	{ "snake", "snake_case", func(words []string) string { return strings.Join(words, "_") } },
+	{ "screaming", "SCREAMING_SNAKE_CASE", func(words []string) string { return strings.ToUpper(strings.Join(words, "_")) } },
+	{ "kebab", "kebab-case", func(words []string) string { return strings.Join(words, "-") } },
+	{ "dot", "dot.case", func(words []string) string { return strings.Join(words, ".") } },
+	{
+		"title", "Title Case",
+		func(words []string) string {
+			var output []string
+			for _, word := range words {
+				output = append(output, capitalize(word))
+			}
+			return strings.Join(output, " ")
+		},
+	},
+}
+
+func findCaseStyle(unit string) (caseStyle, bool) {
+	unit = strings.ToLower(unit)
+	for _, s := range caseStyles {
+		if s.unit == unit { return s, true }
+	}
+	return caseStyle{}, false
+}
+
+func addCaseConversions(output *Conversions) {
+	for _, from := range caseStyles {
+		for _, to := range caseStyles {
+			if from.unit == to.unit { continue }
+			to := to
+			output.Add(Conversion{
+				"case", from.unit, to.unit, func(input string) (string, error) {
+					// Each line is converted separately, so that lists of names can be piped in
+					lines := strings.Split(input, "\n")
+					for i, line := range lines {
+						lines[i] = to.format(identifierWords(line))
+					}
+					return strings.Join(lines, "\n"), nil
+				},
+			})
+		}
+	}
+}
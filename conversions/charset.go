@@ -0,0 +1,446 @@
+package conversions
+
+import (
+	"bufio"
+	"compress/gzip"
+	"embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Tables of the multi-byte charsets. See charsets/generate.py for their format.
+//go:embed charsets/*.bin.gz
+var charsetTables embed.FS
+
+var errInvalidSequence = errors.New("Invalid byte sequence")
+
+// Reads the next character and returns it with the number of bytes it took. Invalid
+// sequences return errInvalidSequence.
+type charsetDecoder func(input *bufio.Reader) (rune, int, error)
+
+// Writes a character, or returns false if the charset can't represent it
+type charsetEncoder func(output *bufio.Writer, r rune) bool
+
+type charset struct {
+	unit string
+	name string
+	// The decoders and encoders can have state, such as the byte order read from a BOM
+	newDecoder func() charsetDecoder
+	newEncoder func() charsetEncoder
+}
+
+func decodeUtf8() charsetDecoder {
+	first := true
+	return func(input *bufio.Reader) (rune, int, error) {
+		r, size, err := input.ReadRune()
+		if err != nil { return 0, 0, err }
+		// A BOM at the start is skipped
+		if first && r == 0xfeff {
+			first = false
+			r, size, err = input.ReadRune()
+			if err != nil { return 0, 0, err }
+			size += 3
+		}
+		first = false
+		if r == utf8.RuneError && size == 1 { return r, size, errInvalidSequence }
+		return r, size, nil
+	}
+}
+
+func encodeUtf8() charsetEncoder {
+	return func(output *bufio.Writer, r rune) bool {
+		output.WriteRune(r)
+		return true
+	}
+}
+
+// Decodes UTF-16 in the given byte order. With detectBom, the byte order is taken from
+// the BOM if there is one, and a BOM in the given byte order is always skipped.
+func decodeUtf16(order binary.ByteOrder, detectBom bool) func() charsetDecoder {
+	return func() charsetDecoder {
+		first := true
+		order := order
+		readUnit := func(input *bufio.Reader) (uint16, error) {
+			b, err := input.Peek(2)
+			if len(b) == 1 { return 0, errInvalidSequence }
+			if err != nil { return 0, err }
+			return order.Uint16(b), nil
+		}
+		return func(input *bufio.Reader) (rune, int, error) {
+			bomSize := 0
+			if first {
+				first = false
+				b, _ := input.Peek(2)
+				if len(b) == 2 {
+					if detectBom && b[0] == 0xff && b[1] == 0xfe { order = binary.LittleEndian }
+					if detectBom && b[0] == 0xfe && b[1] == 0xff { order = binary.BigEndian }
+					if order.Uint16(b) == 0xfeff {
+						input.Discard(2)
+						bomSize = 2
+					}
+				}
+			}
+			unit, err := readUnit(input)
+			if err == errInvalidSequence {
+				input.Discard(1)
+				return utf8.RuneError, bomSize + 1, err
+			}
+			if err != nil { return 0, 0, err }
+			input.Discard(2)
+			if !utf16.IsSurrogate(rune(unit)) { return rune(unit), bomSize + 2, nil }
+			if unit >= 0xdc00 { return utf8.RuneError, bomSize + 2, errInvalidSequence }
+			// A high surrogate must be followed by a low one, which is only consumed if it is
+			next, err := readUnit(input)
+			if err != nil || next < 0xdc00 || next > 0xdfff { return utf8.RuneError, bomSize + 2, errInvalidSequence }
+			input.Discard(2)
+			return utf16.DecodeRune(rune(unit), rune(next)), bomSize + 4, nil
+		}
+	}
+}
+
+func encodeUtf16(order binary.ByteOrder, writeBom bool) func() charsetEncoder {
+	return func() charsetEncoder {
+		first := true
+		writeUnit := func(output *bufio.Writer, unit uint16) {
+			var b [2]byte
+			order.PutUint16(b[:], unit)
+			output.Write(b[:])
+		}
+		return func(output *bufio.Writer, r rune) bool {
+			if first && writeBom { writeUnit(output, 0xfeff) }
+			first = false
+			if r >= 0x10000 {
+				high, low := utf16.EncodeRune(r)
+				writeUnit(output, uint16(high))
+				writeUnit(output, uint16(low))
+			} else {
+				writeUnit(output, uint16(r))
+			}
+			return true
+		}
+	}
+}
+
+// Single-byte charsets, given the characters of their upper half
+
+var windows1252Upper = func() [128]rune {
+	var output [128]rune
+	copy(output[:], []rune{
+		0x20ac, 0x0081, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+		0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008d, 0x017d, 0x008f,
+		0x0090, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+		0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0x009d, 0x017e, 0x0178,
+	})
+	for i := 0x20; i < 0x80; i++ {
+		output[i] = rune(0x80 + i)
+	}
+	return output
+}()
+
+var latin1Upper = func() [128]rune {
+	var output [128]rune
+	for i := range output {
+		output[i] = rune(0x80 + i)
+	}
+	return output
+}()
+
+var koi8rUpper = [128]rune{
+	0x2500, 0x2502, 0x250c, 0x2510, 0x2514, 0x2518, 0x251c, 0x2524,
+	0x252c, 0x2534, 0x253c, 0x2580, 0x2584, 0x2588, 0x258c, 0x2590,
+	0x2591, 0x2592, 0x2593, 0x2320, 0x25a0, 0x2219, 0x221a, 0x2248,
+	0x2264, 0x2265, 0x00a0, 0x2321, 0x00b0, 0x00b2, 0x00b7, 0x00f7,
+	0x2550, 0x2551, 0x2552, 0x0451, 0x2553, 0x2554, 0x2555, 0x2556,
+	0x2557, 0x2558, 0x2559, 0x255a, 0x255b, 0x255c, 0x255d, 0x255e,
+	0x255f, 0x2560, 0x2561, 0x0401, 0x2562, 0x2563, 0x2564, 0x2565,
+	0x2566, 0x2567, 0x2568, 0x2569, 0x256a, 0x256b, 0x256c, 0x00a9,
+	0x044e, 0x0430, 0x0431, 0x0446, 0x0434, 0x0435, 0x0444, 0x0433,
+	0x0445, 0x0438, 0x0439, 0x043a, 0x043b, 0x043c, 0x043d, 0x043e,
+	0x043f, 0x044f, 0x0440, 0x0441, 0x0442, 0x0443, 0x0436, 0x0432,
+	0x044c, 0x044b, 0x0437, 0x0448, 0x044d, 0x0449, 0x0447, 0x044a,
+	0x042e, 0x0410, 0x0411, 0x0426, 0x0414, 0x0415, 0x0424, 0x0413,
+	0x0425, 0x0418, 0x0419, 0x041a, 0x041b, 0x041c, 0x041d, 0x041e,
+	0x041f, 0x042f, 0x0420, 0x0421, 0x0422, 0x0423, 0x0416, 0x0412,
+	0x042c, 0x042b, 0x0417, 0x0428, 0x042d, 0x0429, 0x0427, 0x042a,
+}
+
+func singleByteCharset(unit string, name string, upper [128]rune) charset {
+	encodeMap := make(map[rune]byte)
+	for i, r := range upper {
+		encodeMap[r] = byte(0x80 + i)
+	}
+	return charset{
+		unit, name,
+		func() charsetDecoder {
+			return func(input *bufio.Reader) (rune, int, error) {
+				b, err := input.ReadByte()
+				if err != nil { return 0, 0, err }
+				if b < 0x80 { return rune(b), 1, nil }
+				return upper[b - 0x80], 1, nil
+			}
+		},
+		func() charsetEncoder {
+			return func(output *bufio.Writer, r rune) bool {
+				if r < 0x80 { return output.WriteByte(byte(r)) == nil }
+				b, ok := encodeMap[r]
+				if ok { output.WriteByte(b) }
+				return ok
+			}
+		},
+	}
+}
+
+// Multi-byte charsets, whose tables are loaded the first time they are used
+
+type multiByteTable struct {
+	decode map[uint16]rune
+	encode map[rune]uint16
+	leads [256]bool
+}
+
+type multiByteTableLoader struct {
+	once sync.Once
+	table *multiByteTable
+	err error
+}
+
+var multiByteTables = map[string]*multiByteTableLoader{}
+var multiByteTablesMutex sync.Mutex
+
+func readCharsetTable(name string) ([]byte, error) {
+	file, err := charsetTables.Open("charsets/" + name + ".bin.gz")
+	if err != nil { return nil, err }
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil { return nil, err }
+	return ioutil.ReadAll(reader)
+}
+
+func loadMultiByteTable(name string) (*multiByteTable, error) {
+	multiByteTablesMutex.Lock()
+	loader, exists := multiByteTables[name]
+	if !exists {
+		loader = &multiByteTableLoader{}
+		multiByteTables[name] = loader
+	}
+	multiByteTablesMutex.Unlock()
+
+	loader.once.Do(func() {
+		data, err := readCharsetTable(name)
+		if err != nil {
+			loader.err = err
+			return
+		}
+		table := &multiByteTable{ make(map[uint16]rune), make(map[rune]uint16), [256]bool{} }
+		for i := 0; i + 4 <= len(data); i += 4 {
+			code := binary.BigEndian.Uint16(data[i:])
+			r := rune(binary.BigEndian.Uint16(data[i + 2:]))
+			table.decode[code] = r
+			// The first code of a character is the one used to encode it
+			if _, exists := table.encode[r]; !exists { table.encode[r] = code }
+			if code >= 0x100 { table.leads[code >> 8] = true }
+		}
+		loader.table = table
+	})
+	return loader.table, loader.err
+}
+
+// The runs of consecutive characters in the four-byte part of GB18030
+type gb18030Range struct {
+	index uint32
+	r rune
+}
+
+var gb18030Ranges []gb18030Range
+var gb18030RangesOnce sync.Once
+
+// Index of the first four-byte code of the supplementary planes, 0x90308130
+const gb18030SupplementaryIndex = 189000
+
+func loadGb18030Ranges() []gb18030Range {
+	gb18030RangesOnce.Do(func() {
+		data, err := readCharsetTable("gb18030ranges")
+		if err != nil { return }
+		for i := 0; i + 8 <= len(data); i += 8 {
+			gb18030Ranges = append(gb18030Ranges, gb18030Range{ binary.BigEndian.Uint32(data[i:]), rune(binary.BigEndian.Uint32(data[i + 4:])) })
+		}
+	})
+	return gb18030Ranges
+}
+
+func gb18030FourByteRune(index uint32) (rune, bool) {
+	if index >= gb18030SupplementaryIndex {
+		r := rune(index - gb18030SupplementaryIndex + 0x10000)
+		return r, r <= 0x10ffff
+	}
+	ranges := loadGb18030Ranges()
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].index > index }) - 1
+	if i < 0 { return 0, false }
+	r := ranges[i].r + rune(index - ranges[i].index)
+	// The last range ends at the end of the BMP
+	return r, r <= 0xffff
+}
+
+func gb18030FourByteIndex(r rune) (uint32, bool) {
+	if r >= 0x10000 { return uint32(r - 0x10000) + gb18030SupplementaryIndex, r <= 0x10ffff }
+	ranges := loadGb18030Ranges()
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].r > r }) - 1
+	if i < 0 { return 0, false }
+	return ranges[i].index + uint32(r - ranges[i].r), true
+}
+
+func multiByteCharset(unit string, name string, tableName string, fourByte bool) charset {
+	return charset{
+		unit, name,
+		func() charsetDecoder {
+			var table *multiByteTable
+			return func(input *bufio.Reader) (rune, int, error) {
+				if table == nil {
+					var err error
+					table, err = loadMultiByteTable(tableName)
+					if err != nil { return 0, 0, err }
+				}
+				b, err := input.ReadByte()
+				if err != nil { return 0, 0, err }
+				if b < 0x80 { return rune(b), 1, nil }
+				if r, ok := table.decode[uint16(b)]; ok { return r, 1, nil }
+				if !table.leads[b] { return utf8.RuneError, 1, errInvalidSequence }
+				next, err := input.Peek(1)
+				if err != nil { return utf8.RuneError, 1, errInvalidSequence }
+				if fourByte && next[0] >= 0x30 && next[0] <= 0x39 {
+					rest, _ := input.Peek(3)
+					if len(rest) < 3 || rest[1] < 0x81 || rest[1] > 0xfe || rest[2] < 0x30 || rest[2] > 0x39 { return utf8.RuneError, 1, errInvalidSequence }
+					input.Discard(3)
+					index := (uint32(b - 0x81) * 10 + uint32(rest[0] - 0x30)) * 1260 + uint32(rest[1] - 0x81) * 10 + uint32(rest[2] - 0x30)
+					r, ok := gb18030FourByteRune(index)
+					if !ok { return utf8.RuneError, 4, errInvalidSequence }
+					return r, 4, nil
+				}
+				r, ok := table.decode[uint16(b) << 8 | uint16(next[0])]
+				if ok {
+					input.Discard(1)
+					return r, 2, nil
+				}
+				// An ASCII byte after a lead byte is not part of the invalid sequence
+				if next[0] < 0x80 { return utf8.RuneError, 1, errInvalidSequence }
+				input.Discard(1)
+				return utf8.RuneError, 2, errInvalidSequence
+			}
+		},
+		func() charsetEncoder {
+			var table *multiByteTable
+			return func(output *bufio.Writer, r rune) bool {
+				if r < 0x80 { return output.WriteByte(byte(r)) == nil }
+				if table == nil {
+					var err error
+					table, err = loadMultiByteTable(tableName)
+					if err != nil { return false }
+				}
+				if code, ok := table.encode[r]; ok {
+					if code >= 0x100 { output.WriteByte(byte(code >> 8)) }
+					output.WriteByte(byte(code))
+					return true
+				}
+				if !fourByte || (r >= 0xd800 && r < 0xe000) { return false }
+				index, ok := gb18030FourByteIndex(r)
+				if !ok { return false }
+				var b [4]byte
+				b[3] = byte(index % 10 + 0x30)
+				index /= 10
+				b[2] = byte(index % 126 + 0x81)
+				index /= 126
+				b[1] = byte(index % 10 + 0x30)
+				b[0] = byte(index / 10 + 0x81)
+				output.Write(b[:])
+				return true
+			}
+		},
+	}
+}
+
+var charsets = []charset{
+	{ "utf8", "UTF-8", decodeUtf8, encodeUtf8 },
+	{ "utf16", "UTF-16 (byte order from the BOM, written big-endian with a BOM)", decodeUtf16(binary.BigEndian, true), encodeUtf16(binary.BigEndian, true) },
+	{ "utf16le", "UTF-16LE", decodeUtf16(binary.LittleEndian, false), encodeUtf16(binary.LittleEndian, false) },
+	{ "utf16be", "UTF-16BE", decodeUtf16(binary.BigEndian, false), encodeUtf16(binary.BigEndian, false) },
+	singleByteCharset("latin1", "ISO-8859-1 (Latin-1)", latin1Upper),
+	singleByteCharset("cp1252", "Windows-1252", windows1252Upper),
+	singleByteCharset("koi8r", "KOI8-R", koi8rUpper),
+	multiByteCharset("shiftjis", "Shift_JIS (Windows-31J)", "shiftjis", false),
+	multiByteCharset("euckr", "EUC-KR (Windows-949)", "euckr", false),
+	multiByteCharset("gb18030", "GB18030", "gb18030", true),
+}
+
+func findCharset(unit string) (charset, bool) {
+	unit = strings.ToLower(unit)
+	for _, c := range charsets {
+		if c.unit == unit { return c, true }
+	}
+	return charset{}, false
+}
+
+// Whether transcoding fails on invalid input and unmappable characters, or replaces them
+func (this *Conversions) charsetStrict() (bool, error) {
+	mode := strings.ToLower(this.option("charset-errors", "strict"))
+	switch mode {
+		case "strict": return true, nil
+		case "replace": return false, nil
+	}
+	return false, errors.New("Invalid charset error mode, expected \"strict\" or \"replace\": \"" + mode + "\"")
+}
+
+// Transcodes the input, replacing invalid sequences with U+FFFD and characters that
+// can't be encoded with "?" when not in strict mode.
+func (this *Conversions) transcode(from charset, to charset, input io.Reader, output io.Writer) error {
+	strict, err := this.charsetStrict()
+	if err != nil { return err }
+	reader := bufio.NewReader(input)
+	writer := bufio.NewWriter(output)
+	decode := from.newDecoder()
+	encode := to.newEncoder()
+	offset := 0
+	for {
+		r, size, err := decode(reader)
+		if err == io.EOF { break }
+		if err == errInvalidSequence {
+			if strict {
+				writer.Flush()
+				return fmt.Errorf("Invalid %s sequence at byte %d", from.name, offset)
+			}
+		} else if err != nil {
+			return err
+		}
+		if !encode(writer, r) {
+			if strict {
+				writer.Flush()
+				return fmt.Errorf("Character %U at byte %d can't be encoded in %s", r, offset, to.name)
+			}
+			encode(writer, '?')
+		}
+		offset += size
+	}
+	return writer.Flush()
+}
+
+func addCharsetConversions(output *Conversions) {
+	for _, from := range charsets {
+		for _, to := range charsets {
+			if from.unit == to.unit { continue }
+			from := from
+			to := to
+			output.AddStream(StreamConversion{
+				"charset", from.unit, to.unit, func(input io.Reader, w io.Writer) error {
+					return output.transcode(from, to, input, w)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,62 @@
+#!/usr/bin/env python3
+# Generates the multi-byte charset tables from the codecs of Python's standard library:
+#
+#    cd conversions/charsets && python3 generate.py
+#
+# Each table is a gzipped list of big-endian (code, code point) uint16 pairs. Codes
+# below 0x100 are single bytes. When several codes decode to the same character, the
+# one that Python encodes it to comes first, and is the one used for encoding.
+#
+# gb18030ranges.bin.gz lists the (index, code point) uint32 pairs that start each run
+# of consecutive BMP characters in the four-byte part of GB18030, where the index of
+# b1 b2 b3 b4 is ((b1 - 0x81) * 10 + b2 - 0x30) * 1260 + (b3 - 0x81) * 10 + b4 - 0x30.
+
+import gzip
+import struct
+
+def double_byte_table(codec, leads, trails):
+    decoded = []
+    for b in range(0x100):
+        try:
+            c = bytes([b]).decode(codec)
+            if len(c) == 1: decoded.append((b, ord(c)))
+        except UnicodeDecodeError:
+            pass
+    for lead in leads:
+        for trail in trails:
+            try:
+                c = bytes([lead, trail]).decode(codec)
+                if len(c) == 1 and ord(c) < 0x10000: decoded.append((lead << 8 | trail, ord(c)))
+            except UnicodeDecodeError:
+                pass
+    canonical = []
+    others = []
+    for code, rune in decoded:
+        encoded = chr(rune).encode(codec)
+        if int.from_bytes(encoded, "big") == code:
+            canonical.append((code, rune))
+        else:
+            others.append((code, rune))
+    return canonical + others
+
+def write_table(name, pairs, format):
+    with gzip.GzipFile(name, "wb", mtime=0) as f:
+        for pair in pairs:
+            f.write(struct.pack(format, *pair))
+
+write_table("shiftjis.bin.gz", double_byte_table("cp932", list(range(0x81, 0xa0)) + list(range(0xe0, 0xfd)), range(0x40, 0xfd)), ">HH")
+write_table("euckr.bin.gz", double_byte_table("cp949", range(0x81, 0xff), range(0x41, 0xff)), ">HH")
+write_table("gb18030.bin.gz", double_byte_table("gb18030", range(0x81, 0xff), list(range(0x40, 0x7f)) + list(range(0x80, 0xff))), ">HH")
+
+ranges = []
+previous = None
+for rune in range(0x80, 0x10000):
+    if 0xd800 <= rune < 0xe000: continue
+    encoded = chr(rune).encode("gb18030")
+    if len(encoded) != 4: continue
+    b1, b2, b3, b4 = encoded
+    index = ((b1 - 0x81) * 10 + b2 - 0x30) * 1260 + (b3 - 0x81) * 10 + b4 - 0x30
+    if previous is None or index - previous[0] != rune - previous[1]:
+        ranges.append((index, rune))
+    previous = (index, rune)
+write_table("gb18030ranges.bin.gz", ranges, ">II")
@@ -0,0 +1,370 @@
+package conversions
+
+import (
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type cjkNumeralStyle struct {
+	unit string
+	name string
+	digits []rune
+	// Ten, hundred and thousand
+	smallUnits []string
+	// Myriads: 10^4, 10^8, 10^12 and 10^16, or only the first two when they are nested
+	largeUnits []string
+	// Whether 10^12 and 10^16 are written "万亿" and "亿亿", as in mainland China, where
+	// "兆" means 10^6
+	nestsMyriads bool
+	minus string
+	point string
+	// Whether a zero inside a number is written, as in "一百零五"
+	writesZeros bool
+	// Whether "one" is written before ten at the start of a number, as in "壹拾"
+	oneBeforeLeadingTen bool
+	// Whether "one" is written before ten, hundred and thousand
+	oneBeforeSmallUnits bool
+	// Whether "one" is written before 10^4 alone, Korean writing "만" rather than "일만"
+	oneBeforeMyriad bool
+	groupSeparator string
+}
+
+var cjkNumeralStyles = []cjkNumeralStyle{
+	{
+		"zh", "Chinese Numerals (Simplified)", []rune("零一二三四五六七八九"), []string{ "十", "百", "千" }, []string{ "万", "亿" }, true,
+		"负", "点", true, false, true, true, "",
+	},
+	{
+		"zht", "Chinese Numerals (Traditional)", []rune("零一二三四五六七八九"), []string{ "十", "百", "千" }, []string{ "萬", "億", "兆", "京" }, false,
+		"負", "點", true, false, true, true, "",
+	},
+	{
+		"zhfin", "Chinese Financial Numerals (Simplified)", []rune("零壹贰叁肆伍陆柒捌玖"), []string{ "拾", "佰", "仟" }, []string{ "万", "亿" }, true,
+		"负", "点", true, true, true, true, "",
+	},
+	{
+		"zhtfin", "Chinese Financial Numerals (Traditional)", []rune("零壹貳參肆伍陸柒捌玖"), []string{ "拾", "佰", "仟" }, []string{ "萬", "億", "兆", "京" }, false,
+		"負", "點", true, true, true, true, "",
+	},
+	{
+		"ja", "Japanese Kanji Numerals", []rune("〇一二三四五六七八九"), []string{ "十", "百", "千" }, []string{ "万", "億", "兆", "京" }, false,
+		"マイナス", "・", false, false, false, true, "",
+	},
+	{
+		"ko", "Sino-Korean Numerals", []rune("영일이삼사오육칠팔구"), []string{ "십", "백", "천" }, []string{ "만", "억", "조", "경" }, false,
+		"마이너스 ", "점", false, false, false, false, " ",
+	},
+}
+
+func findCjkNumeralStyle(unit string) (cjkNumeralStyle, bool) {
+	unit = strings.ToLower(unit)
+	for _, s := range cjkNumeralStyles {
+		if s.unit == unit { return s, true }
+	}
+	return cjkNumeralStyle{}, false
+}
+
+// Characters of all the styles, and the variants found in handwriting and older texts
+var cjkNumeralValues = map[rune]uint64{
+	'〇': 0, '零': 0, '영': 0, '공': 0,
+	'一': 1, '壹': 1, '弌': 1, '일': 1,
+	'二': 2, '贰': 2, '貳': 2, '弍': 2, '两': 2, '兩': 2, '이': 2,
+	'三': 3, '叁': 3, '參': 3, '叄': 3, '弎': 3, '삼': 3,
+	'四': 4, '肆': 4, '사': 4,
+	'五': 5, '伍': 5, '오': 5,
+	'六': 6, '陆': 6, '陸': 6, '육': 6, '륙': 6,
+	'七': 7, '柒': 7, '칠': 7,
+	'八': 8, '捌': 8, '팔': 8,
+	'九': 9, '玖': 9, '구': 9,
+}
+
+var cjkUnitValues = map[rune]uint64{
+	'十': 10, '拾': 10, '什': 10, '십': 10,
+	'百': 100, '佰': 100, '백': 100,
+	'千': 1000, '仟': 1000, '천': 1000,
+	'万': 1e4, '萬': 1e4, '만': 1e4,
+	'亿': 1e8, '億': 1e8, '억': 1e8,
+	'兆': 1e12, '조': 1e12,
+	'京': 1e16, '경': 1e16,
+}
+
+// Writes a group of four digits
+func (this cjkNumeralStyle) formatGroup(n uint64, leading bool) string {
+	output := ""
+	pendingZero := false
+	for position := 3; position >= 0; position-- {
+		divisor := uint64(1)
+		for i := 0; i < position; i++ {
+			divisor *= 10
+		}
+		digit := n / divisor % 10
+		if digit == 0 {
+			if output != "" { pendingZero = true }
+			continue
+		}
+		if pendingZero && this.writesZeros { output += string(this.digits[0]) }
+		pendingZero = false
+		if position == 0 {
+			output += string(this.digits[digit])
+			continue
+		}
+		writeOne := this.oneBeforeSmallUnits
+		if position == 1 && leading && output == "" { writeOne = this.oneBeforeLeadingTen }
+		if digit != 1 || writeOne { output += string(this.digits[digit]) }
+		output += this.smallUnits[position - 1]
+	}
+	return output
+}
+
+// Writes a number grouped by myriads, eg. 10050 is "一万零五十". Leading tells whether the
+// number starts the numeral.
+func (this cjkNumeralStyle) formatMyriads(n uint64, leading bool) string {
+	var groups []uint64
+	for ; n > 0; n /= 10000 {
+		groups = append(groups, n % 10000)
+	}
+	var parts []string
+	pendingZero := false
+	for i := len(groups) - 1; i >= 0; i-- {
+		group := groups[i]
+		if group == 0 {
+			pendingZero = true
+			continue
+		}
+		s := ""
+		// A zero marks the digits that are skipped between groups
+		if len(parts) > 0 && this.writesZeros && (pendingZero || group < 1000) { s += string(this.digits[0]) }
+		pendingZero = false
+		if i == 1 && group == 1 && !this.oneBeforeMyriad {
+			s += this.largeUnits[0]
+		} else {
+			s += this.formatGroup(group, leading && len(parts) == 0)
+			if i > 0 { s += this.largeUnits[i - 1] }
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, this.groupSeparator)
+}
+
+func (this cjkNumeralStyle) formatInteger(n uint64) string {
+	if n == 0 { return string(this.digits[0]) }
+	if !this.nestsMyriads { return this.formatMyriads(n, true) }
+	// Groups of eight digits, followed by as many "亿" as their position
+	var groups []uint64
+	for ; n > 0; n /= 1e8 {
+		groups = append(groups, n % 1e8)
+	}
+	output := ""
+	pendingZero := false
+	for i := len(groups) - 1; i >= 0; i-- {
+		group := groups[i]
+		if group == 0 {
+			pendingZero = true
+			continue
+		}
+		if output != "" && (pendingZero || group < 1e7) { output += string(this.digits[0]) }
+		pendingZero = false
+		output += this.formatMyriads(group, output == "") + strings.Repeat(this.largeUnits[1], i)
+	}
+	return output
+}
+
+func (this cjkNumeralStyle) format(negative bool, whole uint64, fraction string) string {
+	output := this.formatInteger(whole)
+	if fraction != "" {
+		output += this.point
+		for _, digit := range fraction {
+			output += string(this.digits[digit - '0'])
+		}
+	}
+	if negative { output = this.minus + output }
+	return output
+}
+
+func addCjkValues(a uint64, b uint64) (uint64, bool) {
+	sum, carry := bits.Add64(a, b, 0)
+	return sum, carry == 0
+}
+
+func multiplyCjkValues(a uint64, b uint64) (uint64, bool) {
+	high, low := bits.Mul64(a, b)
+	return low, high == 0
+}
+
+// Parses numerals of any of the styles, as well as digits written positionally, eg. "二〇二六".
+// Units must come in decreasing order, except that a larger myriad multiplies the smaller
+// ones before it, eg. "三万五千亿" is 35000 times 10^8, and that "亿" multiplies the myriad
+// right before it, eg. "万亿" is 10^12 and "亿亿" is 10^16. In the styles that write
+// zeros, a single digit at the end, right after a unit, stands for the next lower
+// position, eg. "一百二" is 120, while in the others "百五" is 105.
+func parseCjkNumeral(input string, writesZeros bool) (bool, uint64, string, error) {
+	s := strings.TrimSpace(input)
+	negative := false
+	for _, minus := range []string{ "负", "負", "マイナス", "마이너스", "-" } {
+		if strings.HasPrefix(s, minus) {
+			negative = true
+			s = strings.TrimSpace(strings.TrimPrefix(s, minus))
+			break
+		}
+	}
+	invalid := errors.New("Invalid numeral: \"" + input + "\"")
+	tooLarge := errors.New("Numeral is too large: \"" + input + "\"")
+	type part struct {
+		value uint64
+		// The position of the part, eg. 10^12 for "万亿"
+		unit uint64
+	}
+	var parts []part
+	// The value of the digits and small units since the last myriad
+	section := uint64(0)
+	smallUnit := uint64(0)
+	// The digits since the last unit, and the unit they follow
+	number := uint64(0)
+	digits := 0
+	previousUnit := uint64(0)
+	afterZero := false
+	afterMyriad := false
+	fraction := ""
+	inFraction := false
+	found := false
+
+	// Adds the digits at the end of the whole part
+	finish := func() (uint64, error) {
+		if writesZeros && digits == 1 && !afterZero && previousUnit >= 100 {
+			number *= previousUnit / 10
+		} else if previousUnit > 0 && number >= previousUnit {
+			return 0, invalid
+		}
+		whole := section + number
+		if len(parts) > 0 && whole >= parts[len(parts) - 1].unit { return 0, invalid }
+		for _, p := range parts {
+			var ok bool
+			if whole, ok = addCjkValues(whole, p.value); !ok { return 0, tooLarge }
+		}
+		return whole, nil
+	}
+
+	whole := uint64(0)
+	for _, r := range s {
+		value, isDigit := cjkNumeralValues[r]
+		if r >= '0' && r <= '9' {
+			value, isDigit = uint64(r - '0'), true
+		} else if r >= '０' && r <= '９' {
+			value, isDigit = uint64(r - '０'), true
+		}
+		unit := cjkUnitValues[r]
+		switch {
+			case unicode.IsSpace(r) || r == ',':
+				// Korean separates the myriads, eg. "일억 만"
+				afterMyriad = false
+				continue
+			case strings.ContainsRune("点點・점.", r):
+				if inFraction { return false, 0, "", errors.New("Invalid numeral, several decimal points: \"" + input + "\"") }
+				var err error
+				if whole, err = finish(); err != nil { return false, 0, "", err }
+				inFraction = true
+			case isDigit && inFraction:
+				fraction += strconv.FormatUint(value, 10)
+			case isDigit:
+				afterMyriad = false
+				// A zero after a unit marks the positions that are skipped, eg. "一千零二"
+				if value == 0 && digits == 0 && previousUnit > 0 {
+					afterZero = true
+					break
+				}
+				var ok bool
+				if number, ok = multiplyCjkValues(number, 10); ok { number, ok = addCjkValues(number, value) }
+				if !ok { return false, 0, "", tooLarge }
+				digits++
+			case inFraction:
+				return false, 0, "", errors.New("Invalid numeral, unit after the decimal point: \"" + input + "\"")
+			case unit >= 1e4:
+				if afterMyriad {
+					last := &parts[len(parts) - 1]
+					if unit != 1e8 || (last.unit != 1e4 && last.unit != 1e8) { return false, 0, "", invalid }
+					var ok bool
+					if last.value, ok = multiplyCjkValues(last.value, unit); !ok { return false, 0, "", tooLarge }
+					last.unit *= unit
+					if len(parts) > 1 && last.value >= parts[len(parts) - 2].unit { return false, 0, "", invalid }
+					previousUnit = last.unit
+					break
+				}
+				amount := section + number
+				// Larger myriads multiply the smaller ones before them
+				for len(parts) > 0 && parts[len(parts) - 1].unit < unit {
+					amount += parts[len(parts) - 1].value
+					parts = parts[:len(parts) - 1]
+				}
+				if amount == 0 { amount = 1 }
+				value, ok := multiplyCjkValues(amount, unit)
+				if !ok { return false, 0, "", tooLarge }
+				if len(parts) > 0 && value >= parts[len(parts) - 1].unit { return false, 0, "", invalid }
+				parts = append(parts, part{ value, unit })
+				section, smallUnit, number, digits, previousUnit, afterZero, afterMyriad = 0, 0, 0, 0, unit, false, true
+			case unit > 0:
+				if (smallUnit > 0 && unit >= smallUnit) || number >= 10 { return false, 0, "", invalid }
+				if number == 0 { number = 1 }
+				section += number * unit
+				smallUnit, number, digits, previousUnit, afterZero, afterMyriad = unit, 0, 0, unit, false, false
+			default:
+				return false, 0, "", errors.New("Invalid numeral character '" + string(r) + "': \"" + input + "\"")
+		}
+		found = true
+	}
+	if !found { return false, 0, "", invalid }
+	if !inFraction {
+		var err error
+		if whole, err = finish(); err != nil { return false, 0, "", err }
+	}
+	return negative, whole, fraction, nil
+}
+
+func formatDecimal(negative bool, whole uint64, fraction string) string {
+	output := strconv.FormatUint(whole, 10)
+	if fraction != "" { output += "." + fraction }
+	if negative && (whole > 0 || strings.Trim(fraction, "0") != "") { output = "-" + output }
+	return output
+}
+
+func addCjkNumeralConversions(output *Conversions) {
+	for _, style := range cjkNumeralStyles {
+		style := style
+		output.Add(Conversion{
+			"number", "dec", style.unit, func(input string) (string, error) {
+				s := strings.Replace(strings.TrimSpace(input), "_", "", -1)
+				negative := strings.HasPrefix(s, "-")
+				parts := strings.SplitN(strings.TrimLeft(s, "+-"), ".", 2)
+				whole, err := strconv.ParseUint(parts[0], 10, 64)
+				if err != nil || (len(parts) == 2 && (parts[1] == "" || strings.Trim(parts[1], "0123456789") != "")) {
+					return "", errors.New("Invalid number: \"" + input + "\"")
+				}
+				fraction := ""
+				if len(parts) == 2 { fraction = parts[1] }
+				return style.format(negative, whole, fraction), nil
+			},
+		})
+
+		output.Add(Conversion{
+			"number", style.unit, "dec", func(input string) (string, error) {
+				negative, whole, fraction, err := parseCjkNumeral(input, style.writesZeros)
+				if err != nil { return "", err }
+				return formatDecimal(negative, whole, fraction), nil
+			},
+		})
+
+		for _, to := range cjkNumeralStyles {
+			if to.unit == style.unit { continue }
+			to := to
+			output.Add(Conversion{
+				"number", style.unit, to.unit, func(input string) (string, error) {
+					negative, whole, fraction, err := parseCjkNumeral(input, style.writesZeros)
+					if err != nil { return "", err }
+					return to.format(negative, whole, fraction), nil
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package conversions
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed css_colors.txt
+var cssColorsData string
+
+// An sRGB color with components between 0 and 1
+type color struct {
+	r, g, b, a float64
+}
+
+type labColor struct {
+	l, a, b float64
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v * p) / p
+}
+
+func cssColors() map[string]string {
+	output := make(map[string]string)
+	for _, line := range strings.Split(cssColorsData, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' { continue }
+		equalPos := strings.Index(line, "=")
+		if equalPos == -1 { continue }
+		output[line[0:equalPos]] = line[equalPos+1:]
+	}
+	return output
+}
+
+// Splits the arguments of a color such as "rgb(58, 123, 213)", "58,123,213" or
+// "lab(51 3 -55 / 50%)" into its components.
+func colorComponents(input string, functionNames ...string) ([]string, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	for _, name := range functionNames {
+		if strings.HasPrefix(s, name + "(") && strings.HasSuffix(s, ")") {
+			s = s[len(name) + 1:len(s) - 1]
+			break
+		}
+	}
+	s = strings.Replace(s, "/", " ", -1)
+	s = strings.Replace(s, ",", " ", -1)
+	output := strings.Fields(s)
+	if len(output) < 3 { return nil, errors.New("Invalid color: \"" + input + "\"") }
+	return output, nil
+}
+
+// Parses a number, which can be a percentage of the given maximum
+func parseColorNumber(s string, max float64) (float64, error) {
+	if strings.HasSuffix(s, "%") {
+		n, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil { return 0, errors.New("Invalid number: \"" + s + "\"") }
+		return n / 100 * max, nil
+	}
+	s = strings.TrimSuffix(s, "deg")
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil { return 0, errors.New("Invalid number: \"" + s + "\"") }
+	return n, nil
+}
+
+// Parses the components as numbers. Each is divided by its maximum, except the
+// optional alpha component which is always between 0 and 1 (or a percentage).
+func parseColorNumbers(input string, maxes []float64, functionNames ...string) ([]float64, float64, error) {
+	components, err := colorComponents(input, functionNames...)
+	if err != nil { return nil, 0, err }
+	if len(components) != len(maxes) && len(components) != len(maxes) + 1 { return nil, 0, errors.New("Invalid color: \"" + input + "\"") }
+	var output []float64
+	for i, max := range maxes {
+		n, err := parseColorNumber(components[i], max)
+		if err != nil { return nil, 0, err }
+		output = append(output, n)
+	}
+	alpha := 1.0
+	if len(components) > len(maxes) {
+		alpha, err = parseColorNumber(components[len(maxes)], 1)
+		if err != nil { return nil, 0, err }
+	}
+	return output, clamp01(alpha), nil
+}
+
+func parseHexColor(input string) (color, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(input), "#")
+	if len(s) == 3 || len(s) == 4 {
+		expanded := ""
+		for _, c := range s {
+			expanded += string(c) + string(c)
+		}
+		s = expanded
+	}
+	if len(s) != 6 && len(s) != 8 { return color{}, errors.New("Invalid hex color: \"" + input + "\"") }
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil { return color{}, errors.New("Invalid hex color: \"" + input + "\"") }
+	if len(s) == 6 { n = n << 8 | 0xff }
+	return color{ float64(n >> 24) / 255, float64(n >> 16 & 0xff) / 255, float64(n >> 8 & 0xff) / 255, float64(n & 0xff) / 255 }, nil
+}
+
+func to255(v float64) int {
+	return int(math.Round(clamp01(v) * 255))
+}
+
+func formatHexColor(c color) string {
+	output := fmt.Sprintf("#%02x%02x%02x", to255(c.r), to255(c.g), to255(c.b))
+	if c.a < 1 { output += fmt.Sprintf("%02x", to255(c.a)) }
+	return output
+}
+
+func formatColorFunction(name string, components []string, alpha float64) string {
+	if alpha < 1 { return name + "a(" + strings.Join(components, ", ") + ", " + formatFloat(roundTo(alpha, 3)) + ")" }
+	return name + "(" + strings.Join(components, ", ") + ")"
+}
+
+func parseRgbColor(input string) (color, error) {
+	n, alpha, err := parseColorNumbers(input, []float64{ 255, 255, 255 }, "rgb", "rgba")
+	if err != nil { return color{}, err }
+	return color{ clamp01(n[0] / 255), clamp01(n[1] / 255), clamp01(n[2] / 255), alpha }, nil
+}
+
+func formatRgbColor(c color) string {
+	return formatColorFunction("rgb", []string{ strconv.Itoa(to255(c.r)), strconv.Itoa(to255(c.g)), strconv.Itoa(to255(c.b)) }, c.a)
+}
+
+// Returns the hue (in degrees), the chroma and the minimum and maximum components
+func hueChroma(c color) (float64, float64, float64, float64) {
+	max := math.Max(c.r, math.Max(c.g, c.b))
+	min := math.Min(c.r, math.Min(c.g, c.b))
+	chroma := max - min
+	hue := 0.0
+	if chroma > 0 {
+		switch max {
+			case c.r: hue = math.Mod((c.g - c.b) / chroma + 6, 6)
+			case c.g: hue = (c.b - c.r) / chroma + 2
+			default: hue = (c.r - c.g) / chroma + 4
+		}
+	}
+	return hue * 60, chroma, min, max
+}
+
+// Builds a color from a hue, a chroma and a value to add to every component
+func colorFromHueChroma(hue float64, chroma float64, m float64, alpha float64) color {
+	h := math.Mod(math.Mod(hue, 360) + 360, 360) / 60
+	x := chroma * (1 - math.Abs(math.Mod(h, 2) - 1))
+	var r, g, b float64
+	switch {
+		case h < 1: r, g, b = chroma, x, 0
+		case h < 2: r, g, b = x, chroma, 0
+		case h < 3: r, g, b = 0, chroma, x
+		case h < 4: r, g, b = 0, x, chroma
+		case h < 5: r, g, b = x, 0, chroma
+		default: r, g, b = chroma, 0, x
+	}
+	return color{ clamp01(r + m), clamp01(g + m), clamp01(b + m), alpha }
+}
+
+func parseHslColor(input string) (color, error) {
+	n, alpha, err := parseColorNumbers(input, []float64{ 360, 100, 100 }, "hsl", "hsla")
+	if err != nil { return color{}, err }
+	s := clamp01(n[1] / 100)
+	l := clamp01(n[2] / 100)
+	chroma := (1 - math.Abs(2 * l - 1)) * s
+	return colorFromHueChroma(n[0], chroma, l - chroma / 2, alpha), nil
+}
+
+func formatHslColor(c color) string {
+	hue, chroma, min, max := hueChroma(c)
+	l := (max + min) / 2
+	s := 0.0
+	if l > 0 && l < 1 { s = chroma / (1 - math.Abs(2 * l - 1)) }
+	return formatColorFunction("hsl", []string{ formatFloat(roundTo(hue, 1)), formatFloat(roundTo(s * 100, 1)) + "%", formatFloat(roundTo(l * 100, 1)) + "%" }, c.a)
+}
+
+func parseHsvColor(input string) (color, error) {
+	n, alpha, err := parseColorNumbers(input, []float64{ 360, 100, 100 }, "hsv", "hsva", "hsb")
+	if err != nil { return color{}, err }
+	s := clamp01(n[1] / 100)
+	v := clamp01(n[2] / 100)
+	chroma := v * s
+	return colorFromHueChroma(n[0], chroma, v - chroma, alpha), nil
+}
+
+func formatHsvColor(c color) string {
+	hue, chroma, _, max := hueChroma(c)
+	s := 0.0
+	if max > 0 { s = chroma / max }
+	return formatColorFunction("hsv", []string{ formatFloat(roundTo(hue, 1)), formatFloat(roundTo(s * 100, 1)) + "%", formatFloat(roundTo(max * 100, 1)) + "%" }, c.a)
+}
+
+func parseCmykColor(input string) (color, error) {
+	n, alpha, err := parseColorNumbers(input, []float64{ 100, 100, 100, 100 }, "cmyk", "device-cmyk")
+	if err != nil { return color{}, err }
+	k := clamp01(n[3] / 100)
+	return color{ (1 - clamp01(n[0] / 100)) * (1 - k), (1 - clamp01(n[1] / 100)) * (1 - k), (1 - clamp01(n[2] / 100)) * (1 - k), alpha }, nil
+}
+
+func formatCmykColor(c color) string {
+	k := 1 - math.Max(c.r, math.Max(c.g, c.b))
+	cyan, magenta, yellow := 0.0, 0.0, 0.0
+	if k < 1 {
+		cyan = (1 - c.r - k) / (1 - k)
+		magenta = (1 - c.g - k) / (1 - k)
+		yellow = (1 - c.b - k) / (1 - k)
+	}
+	components := []string{}
+	for _, v := range []float64{ cyan, magenta, yellow, k } {
+		components = append(components, formatFloat(roundTo(v * 100, 1)) + "%")
+	}
+	output := "cmyk(" + strings.Join(components, ", ")
+	if c.a < 1 { output += ", " + formatFloat(roundTo(c.a, 3)) }
+	return output + ")"
+}
+
+// CIELAB, relative to the D65 white point
+
+var d65White = [3]float64{ 0.95047, 1, 1.08883 }
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 { return v / 12.92 }
+	return math.Pow((v + 0.055) / 1.055, 2.4)
+}
+
+func linearToSrgb(v float64) float64 {
+	if v <= 0.0031308 { return v * 12.92 }
+	return 1.055 * math.Pow(v, 1 / 2.4) - 0.055
+}
+
+func colorToLab(c color) labColor {
+	r, g, b := srgbToLinear(c.r), srgbToLinear(c.g), srgbToLinear(c.b)
+	xyz := [3]float64{
+		0.4124564 * r + 0.3575761 * g + 0.1804375 * b,
+		0.2126729 * r + 0.7151522 * g + 0.0721750 * b,
+		0.0193339 * r + 0.1191920 * g + 0.9503041 * b,
+	}
+	var f [3]float64
+	for i := range xyz {
+		t := xyz[i] / d65White[i]
+		if t > 216.0 / 24389.0 {
+			f[i] = math.Cbrt(t)
+		} else {
+			f[i] = (24389.0 / 27.0 * t + 16) / 116
+		}
+	}
+	return labColor{ 116 * f[1] - 16, 500 * (f[0] - f[1]), 200 * (f[1] - f[2]) }
+}
+
+func labToColor(lab labColor, alpha float64) color {
+	fy := (lab.l + 16) / 116
+	f := [3]float64{ fy + lab.a / 500, fy, fy - lab.b / 200 }
+	var xyz [3]float64
+	for i := range f {
+		if math.Pow(f[i], 3) > 216.0 / 24389.0 {
+			xyz[i] = math.Pow(f[i], 3) * d65White[i]
+		} else {
+			xyz[i] = (116 * f[i] - 16) / (24389.0 / 27.0) * d65White[i]
+		}
+	}
+	r := 3.2404542 * xyz[0] - 1.5371385 * xyz[1] - 0.4985314 * xyz[2]
+	g := -0.9692660 * xyz[0] + 1.8760108 * xyz[1] + 0.0415560 * xyz[2]
+	b := 0.0556434 * xyz[0] - 0.2040259 * xyz[1] + 1.0572252 * xyz[2]
+	return color{ clamp01(linearToSrgb(r)), clamp01(linearToSrgb(g)), clamp01(linearToSrgb(b)), alpha }
+}
+
+func parseLabColor(input string) (color, error) {
+	components, err := colorComponents(input, "lab")
+	if err != nil { return color{}, err }
+	if len(components) != 3 && len(components) != 4 { return color{}, errors.New("Invalid color: \"" + input + "\"") }
+	var n [3]float64
+	for i, max := range []float64{ 100, 125, 125 } {
+		n[i], err = parseColorNumber(components[i], max)
+		if err != nil { return color{}, err }
+	}
+	alpha := 1.0
+	if len(components) == 4 {
+		alpha, err = parseColorNumber(components[3], 1)
+		if err != nil { return color{}, err }
+	}
+	return labToColor(labColor{ n[0], n[1], n[2] }, clamp01(alpha)), nil
+}
+
+func formatLabColor(c color) string {
+	lab := colorToLab(c)
+	output := "lab(" + formatFloat(roundTo(lab.l, 2)) + " " + formatFloat(roundTo(lab.a, 2)) + " " + formatFloat(roundTo(lab.b, 2))
+	if c.a < 1 { output += " / " + formatFloat(roundTo(c.a, 3)) }
+	return output + ")"
+}
+
+// Color differences
+
+func deltaE76(x labColor, y labColor) float64 {
+	return math.Sqrt(math.Pow(x.l - y.l, 2) + math.Pow(x.a - y.a, 2) + math.Pow(x.b - y.b, 2))
+}
+
+// CIE94, with the weighting factors for graphic arts
+func deltaE94(x labColor, y labColor) float64 {
+	c1 := math.Hypot(x.a, x.b)
+	c2 := math.Hypot(y.a, y.b)
+	dl := x.l - y.l
+	dc := c1 - c2
+	dh2 := math.Pow(x.a - y.a, 2) + math.Pow(x.b - y.b, 2) - dc * dc
+	if dh2 < 0 { dh2 = 0 }
+	sc := 1 + 0.045 * c1
+	sh := 1 + 0.015 * c1
+	return math.Sqrt(dl * dl + math.Pow(dc / sc, 2) + dh2 / (sh * sh))
+}
+
+// CIEDE2000, following "The CIEDE2000 Color-Difference Formula: Implementation Notes"
+// by Sharma, Wu and Dalal.
+func deltaE2000(x labColor, y labColor) float64 {
+	rad := math.Pi / 180
+	c1 := math.Hypot(x.a, x.b)
+	c2 := math.Hypot(y.a, y.b)
+	cMean7 := math.Pow((c1 + c2) / 2, 7)
+	g := 0.5 * (1 - math.Sqrt(cMean7 / (cMean7 + math.Pow(25, 7))))
+	a1 := x.a * (1 + g)
+	a2 := y.a * (1 + g)
+	c1p := math.Hypot(a1, x.b)
+	c2p := math.Hypot(a2, y.b)
+	hue := func(b float64, a float64) float64 {
+		if a == 0 && b == 0 { return 0 }
+		h := math.Atan2(b, a) / rad
+		if h < 0 { h += 360 }
+		return h
+	}
+	h1 := hue(x.b, a1)
+	h2 := hue(y.b, a2)
+
+	dl := y.l - x.l
+	dc := c2p - c1p
+	dh := 0.0
+	if c1p * c2p != 0 {
+		dh = h2 - h1
+		if dh > 180 { dh -= 360 }
+		if dh < -180 { dh += 360 }
+	}
+	dH := 2 * math.Sqrt(c1p * c2p) * math.Sin(dh / 2 * rad)
+
+	lMean := (x.l + y.l) / 2
+	cMean := (c1p + c2p) / 2
+	hMean := h1 + h2
+	if c1p * c2p != 0 {
+		if math.Abs(h1 - h2) <= 180 {
+			hMean = (h1 + h2) / 2
+		} else if h1 + h2 < 360 {
+			hMean = (h1 + h2 + 360) / 2
+		} else {
+			hMean = (h1 + h2 - 360) / 2
+		}
+	}
+	t := 1 - 0.17 * math.Cos((hMean - 30) * rad) + 0.24 * math.Cos(2 * hMean * rad) + 0.32 * math.Cos((3 * hMean + 6) * rad) - 0.20 * math.Cos((4 * hMean - 63) * rad)
+	dTheta := 30 * math.Exp(-math.Pow((hMean - 275) / 25, 2))
+	cMeanP7 := math.Pow(cMean, 7)
+	rc := 2 * math.Sqrt(cMeanP7 / (cMeanP7 + math.Pow(25, 7)))
+	sl := 1 + 0.015 * math.Pow(lMean - 50, 2) / math.Sqrt(20 + math.Pow(lMean - 50, 2))
+	sc := 1 + 0.045 * cMean
+	sh := 1 + 0.015 * cMean * t
+	rt := -math.Sin(2 * dTheta * rad) * rc
+	return math.Sqrt(math.Pow(dl / sl, 2) + math.Pow(dc / sc, 2) + math.Pow(dH / sh, 2) + rt * (dc / sc) * (dH / sh))
+}
+
+func (this *Conversions) deltaE(x color, y color) (float64, string, error) {
+	method := this.option("delta-e", "2000")
+	lx, ly := colorToLab(x), colorToLab(y)
+	switch method {
+		case "76": return deltaE76(lx, ly), "ΔE76", nil
+		case "94": return deltaE94(lx, ly), "ΔE94", nil
+		case "2000": return deltaE2000(lx, ly), "ΔE2000", nil
+	}
+	return 0, "", errors.New("Unknown ΔE method, expected 76, 94 or 2000: \"" + method + "\"")
+}
+
+func (this *Conversions) formatColorName(c color) (string, error) {
+	hex := formatHexColor(color{ c.r, c.g, c.b, 1 })
+	colors := cssColors()
+	var names []string
+	for name := range colors {
+		names = append(names, name)
+	}
+	// Sorted so that the result doesn't depend on the map order when two names have the same color
+	sort.Strings(names)
+	bestName := ""
+	bestDistance := math.Inf(1)
+	methodName := ""
+	for _, name := range names {
+		if colors[name] == hex { return name, nil }
+		other, _ := parseHexColor(colors[name])
+		distance, method, err := this.deltaE(c, other)
+		if err != nil { return "", err }
+		methodName = method
+		if distance < bestDistance {
+			bestDistance = distance
+			bestName = name
+		}
+	}
+	return bestName + " (nearest, " + methodName + " " + formatFloat(roundTo(bestDistance, 2)) + ")", nil
+}
+
+func parseColorName(input string) (color, error) {
+	name := strings.ToLower(strings.Replace(strings.TrimSpace(input), " ", "", -1))
+	if name == "transparent" { return color{ 0, 0, 0, 0 }, nil }
+	hex, exists := cssColors()[name]
+	if !exists { return color{}, errors.New("Unknown color name: \"" + input + "\"") }
+	return parseHexColor(hex)
+}
+
+// Parses a color in any of the supported formats, guessing the format from the input
+func parseAnyColor(input string) (color, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	switch {
+		case strings.HasPrefix(s, "#"): return parseHexColor(s)
+		case strings.HasPrefix(s, "rgb"): return parseRgbColor(s)
+		case strings.HasPrefix(s, "hsl"): return parseHslColor(s)
+		case strings.HasPrefix(s, "hsv") || strings.HasPrefix(s, "hsb"): return parseHsvColor(s)
+		case strings.HasPrefix(s, "cmyk") || strings.HasPrefix(s, "device-cmyk"): return parseCmykColor(s)
+		case strings.HasPrefix(s, "lab"): return parseLabColor(s)
+		case strings.ContainsAny(s, ", "): return parseRgbColor(s)
+	}
+	if c, err := parseColorName(s); err == nil { return c, nil }
+	if c, err := parseHexColor(s); err == nil { return c, nil }
+	return color{}, errors.New("Unrecognized color: \"" + input + "\"")
+}
+
+// WCAG 2 relative luminance
+func relativeLuminance(c color) float64 {
+	return 0.2126 * srgbToLinear(c.r) + 0.7152 * srgbToLinear(c.g) + 0.0722 * srgbToLinear(c.b)
+}
+
+type colorUnit struct {
+	unit string
+	name string
+	parse func(input string) (color, error)
+	format func(c color) (string, error)
+}
+
+func (this *Conversions) colorUnits() []colorUnit {
+	noError := func(f func(c color) string) func(c color) (string, error) {
+		return func(c color) (string, error) { return f(c), nil }
+	}
+	return []colorUnit{
+		{ "hex", "Hexadecimal Color, eg. #3a7bd5", parseHexColor, noError(formatHexColor) },
+		{ "rgb", "RGB, eg. 58,123,213 or rgba(58, 123, 213, 0.5)", parseRgbColor, noError(formatRgbColor) },
+		{ "hsl", "HSL, eg. hsl(216, 65%, 53%)", parseHslColor, noError(formatHslColor) },
+		{ "hsv", "HSV, eg. hsv(216, 73%, 84%)", parseHsvColor, noError(formatHsvColor) },
+		{ "cmyk", "CMYK, eg. cmyk(73%, 42%, 0%, 16%)", parseCmykColor, noError(formatCmykColor) },
+		{ "lab", "CIELAB (D65), eg. lab(51.69 9.72 -52.28)", parseLabColor, noError(formatLabColor) },
+		{ "name", "CSS Color Name, or the nearest one", parseColorName, this.formatColorName },
+	}
+}
+
+func (this *Conversions) findColorUnit(unit string) (colorUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range this.colorUnits() {
+		if u.unit == unit { return u, true }
+	}
+	return colorUnit{}, false
+}
+
+// Returns the 8-bit RGB components of a color in the given unit, eg. to display it
+func (this *Conversions) ColorRgb(unit string, input string) (int, int, int, error) {
+	u, ok := this.findColorUnit(unit)
+	if !ok { return 0, 0, 0, errors.New("Unknown color unit: \"" + unit + "\"") }
+	c, err := u.parse(input)
+	if err != nil { return 0, 0, 0, err }
+	return to255(c.r), to255(c.g), to255(c.b), nil
+}
+
+// Returns the ΔE color difference between two colors in any format
+func (this *Conversions) ColorDifference(input1 string, input2 string) (string, error) {
+	c1, err := parseAnyColor(input1)
+	if err != nil { return "", err }
+	c2, err := parseAnyColor(input2)
+	if err != nil { return "", err }
+	distance, method, err := this.deltaE(c1, c2)
+	if err != nil { return "", err }
+	return method + " " + formatFloat(roundTo(distance, 4)), nil
+}
+
+// Returns the WCAG 2 contrast ratio between two colors in any format, and whether it
+// is enough for the AA and AAA levels.
+func (this *Conversions) ColorContrast(input1 string, input2 string) (string, error) {
+	c1, err := parseAnyColor(input1)
+	if err != nil { return "", err }
+	c2, err := parseAnyColor(input2)
+	if err != nil { return "", err }
+	l1, l2 := relativeLuminance(c1), relativeLuminance(c2)
+	if l2 > l1 { l1, l2 = l2, l1 }
+	ratio := (l1 + 0.05) / (l2 + 0.05)
+	level := func(min float64) string {
+		if ratio >= min { return "pass" }
+		return "fail"
+	}
+	output := fmt.Sprintf("%.2f:1\n", math.Floor(ratio * 100) / 100)
+	output += "AA normal text:   " + level(4.5) + "\n"
+	output += "AA large text:    " + level(3) + "\n"
+	output += "AAA normal text:  " + level(7) + "\n"
+	output += "AAA large text:   " + level(4.5)
+	return output, nil
+}
+
+func addColorConversions(output *Conversions) {
+	for _, from := range output.colorUnits() {
+		for _, to := range output.colorUnits() {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"color", from.unit, to.unit, func(input string) (string, error) {
+					c, err := from.parse(input)
+					if err != nil { return "", err }
+					return to.format(c)
+				},
+			})
+		}
+	}
+}
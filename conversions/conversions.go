@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	"encoding/json"
 	"time"
+	"io"
+	"bytes"
 )
 
 type Conversion struct {
@@ -19,10 +21,25 @@ type Conversion struct {
 	convert func(input string) (string, error)
 }
 
+// A conversion that reads its input and writes its output progressively, so that
+// large files don't need to fit in memory.
+type StreamConversion struct {
+	category string
+	from string
+	to string
+	convert func(input io.Reader, output io.Writer) error
+}
+
 type Conversions struct {
 	inner []Conversion
+	streams []StreamConversion
 	currencies [][]string
 	settings_ *settings.Settings
+	options_ map[string]string
+	// Units that are converted to but never from, such as digests, by category
+	oneWayUnits map[string][]string
+	// The units of each conversion, lowercased, which must be unique across categories
+	pairs map[[2]string]bool
 }
 
 // {lhs: "1 British pound",rhs: "9.2661276 Chinese yuan",error: "",icc: true}
@@ -256,6 +273,31 @@ func NewConversions() *Conversions {
 		}
 	}
 	
+	addTemperatureConversions(output)
+	addCookingConversions(output)
+	addInverseConversions(output)
+	addTypographyConversions(output)
+	addTimeConversions(output)
+	addDurationConversions(output)
+	addCalendarConversions(output)
+	addIdConversions(output)
+	addUuidConversions(output)
+	addColorConversions(output)
+	addNetworkConversions(output)
+	addMacConversions(output)
+	addPermissionConversions(output)
+	addTextConversions(output)
+	addMorseConversions(output)
+	addBrailleConversions(output)
+	addCaseConversions(output)
+	addPunycodeConversions(output)
+	addCharsetConversions(output)
+	addTransliterationConversions(output)
+	addWordsConversions(output)
+	addCjkNumeralConversions(output)
+	addDataConversions(output)
+	addHashConversions(output)
+	
 	return output
 }
 
@@ -265,16 +307,61 @@ func (this *Conversions) settings() *settings.Settings {
 	return this.settings_
 }
 
+// Sets an option that modifies how conversions behave, eg. "delta" for temperature differences.
+func (this *Conversions) SetOption(name string, value string) {
+	if this.options_ == nil { this.options_ = make(map[string]string) }
+	this.options_[name] = value
+}
+
+// Saves the value used for an option when it is not set with SetOption.
+func (this *Conversions) SetDefaultOption(name string, value string) error {
+	return this.settings().SetValue("Defaults", name, value)
+}
+
+func (this *Conversions) DefaultOptions() map[string]string {
+	output := make(map[string]string)
+	for _, name := range this.settings().Names("Defaults") {
+		output[name] = this.settings().Value("Defaults", name, "")
+	}
+	return output
+}
+
+func (this *Conversions) option(name string, defaultValue string) string {
+	output, exists := this.options_[name]
+	if exists { return output }
+	return this.settings().Value("Defaults", name, defaultValue)
+}
+
+func (this *Conversions) optionFloat(name string, defaultValue float64) (float64, error) {
+	s := this.option(name, "")
+	if s == "" { return defaultValue, nil }
+	output, err := strconv.ParseFloat(s, 64)
+	if err != nil { return 0, errors.New("Invalid value for option \"" + name + "\": " + s) }
+	return output, nil
+}
+
 func (this *Conversions) ConvertFormat(format string, from string, to string, input string) (string, error) {
 	result, err := this.Convert(from, to, input)
 	if err != nil { return result, err }
 	oFrom, oTo := this.OriginalUnitNames(from, to)
+	category := this.CategoryName(from, to)
+	oFrom = this.UnitSymbol(category, oFrom)
+	oTo = this.UnitSymbol(category, oTo)
+	return applyFormat(format, input, result, oFrom, oTo), nil
+}
+
+func applyFormat(format string, input string, result string, from string, to string) string {
 	output := format
+	// Multi-line results, such as the fields of a UUID, are displayed below the input
+	if strings.Contains(result, "\n") {
+		output = strings.Replace(output, "%o %v", "%v:\n%o", -1)
+		result = "   " + strings.Replace(result, "\n", "\n   ", -1)
+	}
 	output = strings.Replace(output, "%i", input, -1)
 	output = strings.Replace(output, "%o", result, -1)
-	output = strings.Replace(output, "%u", oFrom, -1)
-	output = strings.Replace(output, "%v", oTo, -1)
-	return output, nil
+	output = strings.Replace(output, "%u", from, -1)
+	output = strings.Replace(output, "%v", to, -1)
+	return output
 }
 
 func (this *Conversions) Convert(from string, to string, input string) (string, error) {
@@ -288,10 +375,81 @@ func (this *Conversions) Convert(from string, to string, input string) (string,
 	return "", errors.New("Unsupported conversion: \"" + from + "\" to \"" + to + "\"") 
 }
 
+func (this *Conversions) HasConversion(from string, to string) bool {
+	return this.CategoryName(from, to) != ""
+}
+
+// Adds a conversion. As commands don't name the category, two conversions between the same
+// units would hide one another, so this panics if the units are already converted between.
 func (this *Conversions) Add(c Conversion) {
+	if this.pairs == nil { this.pairs = make(map[[2]string]bool) }
+	pair := [2]string{ strings.ToLower(c.from), strings.ToLower(c.to) }
+	if this.pairs[pair] { panic("Duplicate conversion from \"" + c.from + "\" to \"" + c.to + "\" in category \"" + c.category + "\"") }
+	this.pairs[pair] = true
 	this.inner = append(this.inner, c)
 }
 
+// Adds a stream conversion, which is also available as a regular conversion of strings.
+func (this *Conversions) AddStream(c StreamConversion) {
+	this.streams = append(this.streams, c)
+	this.Add(Conversion{
+		c.category, c.from, c.to, func(input string) (string, error) {
+			var output bytes.Buffer
+			err := c.convert(strings.NewReader(input), &output)
+			return output.String(), err
+		},
+	})
+}
+
+// Adds a stream conversion whose output ends with a line break, such as a document or a
+// digest. Its string conversion leaves the line break out, as values given on the command
+// line don't have one.
+func (this *Conversions) AddLineStream(c StreamConversion) {
+	this.streams = append(this.streams, c)
+	this.Add(Conversion{
+		c.category, c.from, c.to, func(input string) (string, error) {
+			var output bytes.Buffer
+			err := c.convert(strings.NewReader(input), &output)
+			return strings.TrimSuffix(output.String(), "\n"), err
+		},
+	})
+}
+
+// Marks a unit that can't be converted back from, such as a digest
+func (this *Conversions) markOneWay(category string, unit string) {
+	if this.oneWayUnits == nil { this.oneWayUnits = make(map[string][]string) }
+	this.oneWayUnits[category] = append(this.oneWayUnits[category], unit)
+}
+
+func (this *Conversions) IsOneWayUnit(category string, unit string) bool {
+	for _, u := range this.oneWayUnits[category] {
+		if strings.ToLower(u) == strings.ToLower(unit) { return true }
+	}
+	return false
+}
+
+// Whether the conversion exists and can't be reversed
+func (this *Conversions) IsOneWay(from string, to string) bool {
+	category := this.CategoryName(from, to)
+	return category != "" && this.IsOneWayUnit(category, to)
+}
+
+func (this *Conversions) HasStreamConversion(from string, to string) bool {
+	for _, c := range this.streams {
+		if strings.ToLower(c.from) == strings.ToLower(from) && strings.ToLower(c.to) == strings.ToLower(to) { return true }
+	}
+	return false
+}
+
+func (this *Conversions) ConvertStream(from string, to string, input io.Reader, output io.Writer) error {
+	for _, c := range this.streams {
+		if strings.ToLower(c.from) == strings.ToLower(from) && strings.ToLower(c.to) == strings.ToLower(to) {
+			return c.convert(input, output)
+		}
+	}
+	return errors.New("Unsupported conversion: \"" + from + "\" to \"" + to + "\"")
+}
+
 func (this *Conversions) NiceCategoryName(s string) string {
 	return s
 }
@@ -309,6 +467,34 @@ func (this *Conversions) OriginalUnitNames(from string, to string) (string, stri
 	return from, to
 }
 
+func (this *Conversions) CategoryName(from string, to string) string {
+	lTo := strings.ToLower(to)
+	lFrom := strings.ToLower(from)
+	
+	for _, c := range this.inner {
+		if strings.ToLower(c.to) == lTo && strings.ToLower(c.from) == lFrom {
+			return c.category
+		}
+	}
+	
+	return ""
+}
+
+// Returns the symbol used when displaying a value of the given unit, eg. "°C" for Celsius.
+func (this *Conversions) UnitSymbol(category string, s string) string {
+	if category == "temperature" {
+		if t, ok := findTemperatureScale(s); ok { return t.symbol }
+	}
+	
+	if category == "cooking" {
+		if u, ok := findCookingUnit(s); ok { return u.symbol }
+	}
+	
+	if u, ok := findInverseUnit(category, s); ok { return u.symbol }
+	
+	return s
+}
+
 func (this *Conversions) NiceUnitName(category string, s string) string {
 	s = strings.ToLower(s)
 
@@ -317,6 +503,111 @@ func (this *Conversions) NiceUnitName(category string, s string) string {
 		if s == "bin" { return "Binary" }
 		if s == "dec" { return "Decimal" }
 		if s == "oct" { return "Octal" }
+		if c, ok := findCjkNumeralStyle(s); ok { return c.name }
+	}
+	
+	if category == "temperature" {
+		if t, ok := findTemperatureScale(s); ok { return t.name }
+	}
+	
+	if category == "cooking" {
+		if u, ok := findCookingUnit(s); ok { return u.name }
+	}
+	
+	if u, ok := findInverseUnit(category, s); ok { return u.name }
+	
+	if category == "typography" {
+		if u, ok := findTypographyUnit(s); ok { return u.name }
+	}
+	
+	if category == "time" {
+		if u, ok := findTimeUnit(s); ok { return u.name }
+	}
+	
+	if category == "duration" {
+		if u, ok := findDurationUnit(s); ok { return u.name }
+	}
+	
+	if category == "calendar" {
+		if u, ok := findCalendarUnit(s); ok { return u.name }
+	}
+	
+	if category == "id" {
+		for _, row := range idTypes {
+			if row[0] == s { return row[1] }
+		}
+		if f, ok := findUuidFormat(s); ok { return f.name }
+	}
+	
+	if category == "network" {
+		if u, ok := findNetworkUnit(s); ok { return u.name }
+		if f, ok := findMacFormat(s); ok { return f.name }
+		for _, u := range macUnits {
+			if u.unit == s { return u.name }
+		}
+	}
+	
+	if category == "permission" {
+		if u, ok := findPermissionUnit(s); ok { return u.name }
+	}
+	
+	if category == "text" {
+		if s == "text" { return "Plain Text" }
+		if e, ok := findTextEncoding(s); ok { return e.name }
+		switch s {
+			case "morse": return "Morse Code"
+			case "nato": return "NATO Phonetic Alphabet"
+			case "braille": return "Grade 1 Braille"
+		}
+	}
+	
+	if category == "case" {
+		if c, ok := findCaseStyle(s); ok { return c.name }
+	}
+	
+	if category == "domain" {
+		if s == "unicode" { return "Internationalized Domain Name or URL" }
+		if s == "punycode" { return "Punycode (IDNA) Domain Name or URL" }
+	}
+	
+	if category == "charset" {
+		if c, ok := findCharset(s); ok { return c.name }
+	}
+	
+	if category == "translit" {
+		switch s {
+			case "cyrillic": return "Cyrillic"
+			case "iso9": return "ISO 9 Latin"
+			case "bgn": return "BGN/PCGN Latin"
+			case "greek": return "Greek"
+			case "elot": return "ELOT 743 Latin"
+			case "text": return "Text"
+			case "slug": return "Slug"
+		}
+	}
+	
+	if category == "words" {
+		switch s {
+			case "dec": return "Number"
+			case "words": return "Number in Words"
+			case "ordinal": return "Ordinal Number in Words"
+		}
+		// Amounts are written in words from currencies
+		return this.NiceUnitName("currency", s)
+	}
+	
+	if category == "data" {
+		if f, ok := findDataFormat(s); ok { return f.name }
+	}
+	
+	if category == "hash" {
+		if s == "text" { return "Text" }
+		if s == "file" { return "File (path, --input or stdin)" }
+		if a, ok := findHashAlgorithm(s); ok { return a.name }
+	}
+	
+	if category == "color" {
+		if u, ok := this.findColorUnit(s); ok { return u.name }
 	}
 	
 	if category == "currency" {
@@ -364,5 +655,6 @@ func (this *Conversions) UnitNames(category string) []string {
 			output = append(output, c.from)
 		}		
 	}
-	return output
+	// One-way units are never converted from, so they are listed after the others
+	return append(output, this.oneWayUnits[category]...)
 }
\ No newline at end of file
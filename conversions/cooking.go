@@ -0,0 +1,125 @@
+package conversions
+
+import (
+	_ "embed"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed ingredients.txt
+var ingredientsData string
+
+type cookingUnit struct {
+	unit string
+	name string
+	symbol string
+	isVolume bool
+	factor float64 // In millilitres for volumes, in grams for masses
+}
+
+var cookingUnits = []cookingUnit{
+	{ "cup", "US Cup", "cup", true, 236.5882365 },
+	{ "ukcup", "UK Cup", "UK cup", true, 284.130625 },
+	{ "metriccup", "Metric Cup", "metric cup", true, 250 },
+	{ "tbsp", "Tablespoon", "tbsp", true, 14.78676478125 },
+	{ "tsp", "Teaspoon", "tsp", true, 4.92892159375 },
+	{ "floz", "US Fluid Ounce", "fl oz", true, 29.5735295625 },
+	{ "ukfloz", "UK Fluid Ounce", "UK fl oz", true, 28.4130625 },
+	{ "ml", "Millilitre", "ml", true, 1 },
+	{ "l", "Litre", "l", true, 1000 },
+	{ "g", "Gram", "g", false, 1 },
+	{ "kg", "Kilogram", "kg", false, 1000 },
+	{ "oz", "Ounce", "oz", false, 28.349523125 },
+	{ "lb", "Pound", "lb", false, 453.59237 },
+}
+
+func findCookingUnit(unit string) (cookingUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range cookingUnits {
+		if u.unit == unit { return u, true }
+	}
+	return cookingUnit{}, false
+}
+
+func normalizeIngredientName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Replace(name, "-", " ", -1)
+	name = strings.Replace(name, "_", " ", -1)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func builtInIngredients() map[string]float64 {
+	output := make(map[string]float64)
+	for _, line := range strings.Split(ingredientsData, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' { continue }
+		equalPos := strings.Index(line, "=")
+		if equalPos == -1 { continue }
+		density, err := strconv.ParseFloat(line[equalPos+1:], 64)
+		if err != nil { continue }
+		output[normalizeIngredientName(line[0:equalPos])] = density
+	}
+	return output
+}
+
+// Returns the density of the ingredient in g/ml. User-defined ingredients take
+// precedence over the built-in ones.
+func (this *Conversions) IngredientDensity(name string) (float64, error) {
+	name = normalizeIngredientName(name)
+	density := this.settings().ValueFloat64("Ingredients", name, -1)
+	if density > 0 { return density, nil }
+	density, exists := builtInIngredients()[name]
+	if !exists { return 0, errors.New("Unknown ingredient: \"" + name + "\". Run \"aconv ingredient\" to list them.") }
+	return density, nil
+}
+
+func (this *Conversions) SetIngredientDensity(name string, density float64) error {
+	if density <= 0 { return errors.New("Density must be greater than zero") }
+	return this.settings().SetValueFloat64("Ingredients", normalizeIngredientName(name), density)
+}
+
+func (this *Conversions) IngredientNames() []string {
+	var output []string
+	for name := range builtInIngredients() {
+		output = append(output, name)
+	}
+	for _, name := range this.settings().Names("Ingredients") {
+		if _, exists := builtInIngredients()[name]; !exists { output = append(output, name) }
+	}
+	sort.Strings(output)
+	return output
+}
+
+func addCookingConversions(output *Conversions) {
+	cookingConv := func(input string, from cookingUnit, to cookingUnit) (string, error) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil { return "", err }
+		value *= from.factor
+		if from.isVolume != to.isVolume {
+			ingredient := output.option("ingredient", "")
+			if ingredient == "" { return "", errors.New("Converting between volume and mass requires an ingredient, eg. --ingredient flour") }
+			density, err := output.IngredientDensity(ingredient)
+			if err != nil { return "", err }
+			if from.isVolume {
+				value *= density
+			} else {
+				value /= density
+			}
+		}
+		return formatFloat(value / to.factor), nil
+	}
+
+	for _, from := range cookingUnits {
+		for _, to := range cookingUnits {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"cooking", from.unit, to.unit, func(input string) (string, error) {
+					return cookingConv(input, from, to)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package conversions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Documents are read into a tree of nil, bool, int64, float64, json.Number, string,
+// []interface{} and *dataObject values, which the writers then output.
+
+// An object that keeps its keys in the order of the input
+type dataObject struct {
+	keys []string
+	values map[string]interface{}
+}
+
+func newDataObject() *dataObject {
+	return &dataObject{ nil, make(map[string]interface{}) }
+}
+
+func (this *dataObject) set(key string, value interface{}) {
+	if _, exists := this.values[key]; !exists { this.keys = append(this.keys, key) }
+	this.values[key] = value
+}
+
+type dataFormat struct {
+	unit string
+	name string
+	// Either can be nil if the format is only read or only written
+	read func(this *Conversions, input io.Reader) (interface{}, error)
+	write func(this *Conversions, output io.Writer, value interface{}) error
+}
+
+var dataFormats = []dataFormat{
+	{ "json", "JSON", (*Conversions).readJson, (*Conversions).writeJson },
+	{ "yaml", "YAML", (*Conversions).readYaml, (*Conversions).writeYaml },
+	{ "toml", "TOML", (*Conversions).readToml, nil },
+	{ "csv", "CSV", (*Conversions).readCsv, (*Conversions).writeCsv },
+	{ "xml", "XML", (*Conversions).readXml, nil },
+}
+
+func findDataFormat(unit string) (dataFormat, bool) {
+	unit = strings.ToLower(unit)
+	for _, f := range dataFormats {
+		if f.unit == unit { return f, true }
+	}
+	return dataFormat{}, false
+}
+
+// Quotes a string as in JSON, but without escaping "<", ">" and "&"
+func quoteJsonString(s string) string {
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(output.String(), "\n")
+}
+
+// Formats a number, bool or null as it is written in JSON
+func formatDataScalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+		case nil: return "null", nil
+		case bool: return strconv.FormatBool(v), nil
+		case int64: return strconv.FormatInt(v, 10), nil
+		case json.Number: return string(v), nil
+		case float64:
+			if math.IsInf(v, 0) || math.IsNaN(v) { return "", fmt.Errorf("%v can't be represented in JSON", v) }
+			output, err := json.Marshal(v)
+			if err != nil { return "", err }
+			// Whole floats keep a fraction, so that they are still floats when read back
+			if !strings.ContainsAny(string(output), ".eE") { output = append(output, ".0"...) }
+			return string(output), nil
+	}
+	return "", fmt.Errorf("Unsupported value: %v", value)
+}
+
+// JSON
+
+func (this *Conversions) readJson(input io.Reader) (interface{}, error) {
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+	output, err := readJsonValue(decoder)
+	if err != nil { return nil, err }
+	if _, err := decoder.Token(); err != io.EOF { return nil, errors.New("Invalid JSON: unexpected data after the value") }
+	return output, nil
+}
+
+func readJsonValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err == io.EOF { return nil, errors.New("Invalid JSON: unexpected end of input") }
+	if err != nil { return nil, errors.New("Invalid JSON: " + err.Error()) }
+	switch token {
+		case json.Delim('{'):
+			object := newDataObject()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil { return nil, errors.New("Invalid JSON: " + err.Error()) }
+				value, err := readJsonValue(decoder)
+				if err != nil { return nil, err }
+				object.set(key.(string), value)
+			}
+			_, err := decoder.Token()
+			return object, err
+		case json.Delim('['):
+			array := []interface{}{}
+			for decoder.More() {
+				value, err := readJsonValue(decoder)
+				if err != nil { return nil, err }
+				array = append(array, value)
+			}
+			_, err := decoder.Token()
+			return array, err
+	}
+	return token, nil
+}
+
+func formatJsonValue(output *strings.Builder, value interface{}, indent string) error {
+	switch v := value.(type) {
+		case string:
+			output.WriteString(quoteJsonString(v))
+		case []interface{}:
+			if len(v) == 0 {
+				output.WriteString("[]")
+				return nil
+			}
+			output.WriteString("[\n")
+			for i, item := range v {
+				output.WriteString(indent + "  ")
+				if err := formatJsonValue(output, item, indent + "  "); err != nil { return err }
+				if i < len(v) - 1 { output.WriteString(",") }
+				output.WriteString("\n")
+			}
+			output.WriteString(indent + "]")
+		case *dataObject:
+			if len(v.keys) == 0 {
+				output.WriteString("{}")
+				return nil
+			}
+			output.WriteString("{\n")
+			for i, key := range v.keys {
+				output.WriteString(indent + "  " + quoteJsonString(key) + ": ")
+				if err := formatJsonValue(output, v.values[key], indent + "  "); err != nil { return err }
+				if i < len(v.keys) - 1 { output.WriteString(",") }
+				output.WriteString("\n")
+			}
+			output.WriteString(indent + "}")
+		default:
+			s, err := formatDataScalar(v)
+			if err != nil { return err }
+			output.WriteString(s)
+	}
+	return nil
+}
+
+func (this *Conversions) writeJson(output io.Writer, value interface{}) error {
+	var s strings.Builder
+	if err := formatJsonValue(&s, value, ""); err != nil { return err }
+	_, err := io.WriteString(output, s.String() + "\n")
+	return err
+}
+
+// CSV
+
+// Returns the separator given by the "csv-separator" option, or the character among
+// ",", ";", tab and "|" that is the most frequent on the first line.
+func (this *Conversions) csvSeparator(firstLine string) (rune, error) {
+	separator := this.option("csv-separator", "auto")
+	switch strings.ToLower(separator) {
+		case "auto":
+			output := ','
+			count := 0
+			for _, r := range []rune{ ',', ';', '\t', '|' } {
+				if n := strings.Count(firstLine, string(r)); n > count {
+					output, count = r, n
+				}
+			}
+			return output, nil
+		case "tab", "\\t":
+			return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(separator)
+	if size != len(separator) || r == '"' || r == '\n' || r == '\r' {
+		return 0, errors.New("Invalid CSV separator: \"" + separator + "\"")
+	}
+	return r, nil
+}
+
+func isJsonNumber(s string) bool {
+	return s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s))
+}
+
+// Reads the rows as objects. With the "csv-header" option set to "auto", the first row
+// is taken as the column names if its fields are all different and none of them is empty,
+// a number or a bool. Columns are typed: a column whose values are all numbers or all
+// bools is output as such, with empty values as null.
+func (this *Conversions) readCsv(input io.Reader) (interface{}, error) {
+	reader := bufio.NewReader(input)
+	start, _ := reader.Peek(4096)
+	firstLine := string(start)
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 { firstLine = firstLine[:i] }
+	separator, err := this.csvSeparator(firstLine)
+	if err != nil { return nil, err }
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = separator
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil { return nil, errors.New("Invalid CSV: " + err.Error()) }
+	output := []interface{}{}
+	if len(records) == 0 { return output, nil }
+	if len(records[0]) > 0 { records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff") }
+
+	hasHeader := false
+	switch header := strings.ToLower(this.option("csv-header", "auto")); header {
+		case "yes": hasHeader = true
+		case "no":
+		case "auto":
+			hasHeader = true
+			seen := make(map[string]bool)
+			for _, field := range records[0] {
+				lower := strings.ToLower(strings.TrimSpace(field))
+				if lower == "" || seen[lower] || isJsonNumber(lower) || lower == "true" || lower == "false" { hasHeader = false }
+				seen[lower] = true
+			}
+		default:
+			return nil, errors.New("Invalid value for option \"csv-header\": " + header)
+	}
+	var names []string
+	if hasHeader {
+		for _, field := range records[0] {
+			names = append(names, strings.TrimSpace(field))
+		}
+		records = records[1:]
+	}
+
+	// Whether all the values of each column are numbers or bools
+	var numbers, bools []bool
+	for _, record := range records {
+		for i, field := range record {
+			for len(numbers) <= i {
+				numbers = append(numbers, true)
+				bools = append(bools, true)
+			}
+			lower := strings.ToLower(field)
+			numbers[i] = numbers[i] && (field == "" || isJsonNumber(field))
+			bools[i] = bools[i] && (field == "" || lower == "true" || lower == "false")
+		}
+	}
+	// A column of empty values is a column of strings
+	for i := range numbers {
+		empty := true
+		for _, record := range records {
+			if i < len(record) && record[i] != "" { empty = false }
+		}
+		if empty { numbers[i], bools[i] = false, false }
+	}
+	for len(names) < len(numbers) {
+		names = append(names, "column" + strconv.Itoa(len(names) + 1))
+	}
+
+	for _, record := range records {
+		row := newDataObject()
+		for i, name := range names {
+			if i >= len(record) {
+				row.set(name, nil)
+				continue
+			}
+			field := record[i]
+			switch {
+				case i >= len(numbers) || (!numbers[i] && !bools[i]): row.set(name, field)
+				case field == "": row.set(name, nil)
+				case bools[i]: row.set(name, strings.ToLower(field) == "true")
+				default: row.set(name, json.Number(field))
+			}
+		}
+		output = append(output, row)
+	}
+	return output, nil
+}
+
+// Flattens nested objects and arrays into columns named after their path, eg. "address.city"
+// or "tags.0"
+func flattenData(prefix string, value interface{}, row *dataObject) error {
+	switch v := value.(type) {
+		case *dataObject:
+			if len(v.keys) == 0 && prefix != "" {
+				row.set(prefix, "{}")
+				return nil
+			}
+			for _, key := range v.keys {
+				if err := flattenData(joinDataKey(prefix, key), v.values[key], row); err != nil { return err }
+			}
+		case []interface{}:
+			if len(v) == 0 && prefix != "" {
+				row.set(prefix, "[]")
+				return nil
+			}
+			for i, item := range v {
+				if err := flattenData(joinDataKey(prefix, strconv.Itoa(i)), item, row); err != nil { return err }
+			}
+		case string:
+			row.set(joinDataKey(prefix, ""), v)
+		case nil:
+			row.set(joinDataKey(prefix, ""), "")
+		default:
+			s, err := formatDataScalar(v)
+			if err != nil { return err }
+			row.set(joinDataKey(prefix, ""), s)
+	}
+	return nil
+}
+
+func joinDataKey(prefix string, key string) string {
+	if prefix == "" && key == "" { return "value" }
+	if prefix == "" { return key }
+	if key == "" { return prefix }
+	return prefix + "." + key
+}
+
+// Writes an array of objects as rows, or a single object as one row
+func (this *Conversions) writeCsv(output io.Writer, value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok { items = []interface{}{ value } }
+	separator, err := this.csvSeparator("")
+	if err != nil { return err }
+
+	columns := newDataObject()
+	var rows []*dataObject
+	for _, item := range items {
+		row := newDataObject()
+		if err := flattenData("", item, row); err != nil { return err }
+		for _, key := range row.keys {
+			columns.set(key, nil)
+		}
+		rows = append(rows, row)
+	}
+
+	writer := csv.NewWriter(output)
+	writer.Comma = separator
+	if strings.ToLower(this.option("csv-header", "auto")) != "no" { writer.Write(columns.keys) }
+	for _, row := range rows {
+		record := make([]string, len(columns.keys))
+		for i, key := range columns.keys {
+			if s, ok := row.values[key].(string); ok { record[i] = s }
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// XML
+
+// Reads elements as objects, with attributes as "@name" keys and the text next to child
+// elements as "#text". Elements that only contain text are strings, empty ones are null,
+// and repeated child elements become arrays. Names keep their namespace prefix, eg. "m:x".
+func (this *Conversions) readXml(input io.Reader) (interface{}, error) {
+	// Raw tokens keep the prefixes instead of replacing them with the namespaces
+	decoder := xml.NewDecoder(input)
+	var output *dataObject
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF { break }
+		if err != nil { return nil, errors.New("Invalid XML: " + err.Error()) }
+		switch t := token.(type) {
+			case xml.StartElement:
+				if output != nil { return nil, errors.New("Invalid XML: several root elements") }
+				value, err := readXmlElement(decoder, t)
+				if err != nil { return nil, err }
+				output = newDataObject()
+				output.set(xmlName(t.Name), value)
+			case xml.EndElement:
+				return nil, errors.New("Invalid XML: unexpected end element </" + xmlName(t.Name) + ">")
+			case xml.CharData:
+				if strings.TrimSpace(string(t)) != "" { return nil, errors.New("Invalid XML: text outside of the root element") }
+		}
+	}
+	if output == nil { return nil, errors.New("Invalid XML: no root element") }
+	return output, nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" { return name.Local }
+	return name.Space + ":" + name.Local
+}
+
+func readXmlElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	object := newDataObject()
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") { continue }
+		object.set("@" + xmlName(attr.Name), attr.Value)
+	}
+	repeated := make(map[string]bool)
+	// The text of the element, around and between its child elements
+	text := ""
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF { return nil, errors.New("Invalid XML: element <" + xmlName(start.Name) + "> is not closed") }
+		if err != nil { return nil, errors.New("Invalid XML: " + err.Error()) }
+		switch t := token.(type) {
+			case xml.StartElement:
+				child, err := readXmlElement(decoder, t)
+				if err != nil { return nil, err }
+				name := xmlName(t.Name)
+				existing, exists := object.values[name]
+				if repeated[name] {
+					object.set(name, append(existing.([]interface{}), child))
+				} else if exists {
+					repeated[name] = true
+					object.set(name, []interface{}{ existing, child })
+				} else {
+					object.set(name, child)
+				}
+			case xml.CharData:
+				text += string(t)
+			case xml.EndElement:
+				if t.Name != start.Name { return nil, errors.New("Invalid XML: element <" + xmlName(start.Name) + "> is closed by </" + xmlName(t.Name) + ">") }
+				text = strings.TrimSpace(text)
+				if len(object.keys) == 0 {
+					if text == "" { return nil, nil }
+					return text, nil
+				}
+				if text != "" { object.set("#text", text) }
+				return object, nil
+		}
+	}
+}
+
+func addDataConversions(output *Conversions) {
+	for _, from := range dataFormats {
+		if from.read == nil { continue }
+		for _, to := range dataFormats {
+			if to.write == nil || to.unit == from.unit { continue }
+			from := from
+			to := to
+			output.AddLineStream(StreamConversion{
+				"data", from.unit, to.unit, func(input io.Reader, w io.Writer) error {
+					value, err := from.read(output, input)
+					if err != nil { return err }
+					return to.write(output, w, value)
+				},
+			})
+		}
+	}
+}
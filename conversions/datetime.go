@@ -0,0 +1,152 @@
+package conversions
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type timeUnit struct {
+	unit string
+	name string
+	parse func(input string, location *time.Location, layout string) (time.Time, error)
+	format func(t time.Time, layout string) (string, error)
+}
+
+// Inputs without a time zone are parsed in the selected location
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"20060102T150405Z0700",
+	"20060102T150405Z",
+	"20060102T150405",
+	"2006-01-02",
+	"20060102",
+}
+
+func parseWithLayouts(input string, layouts []string, location *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, input, location)
+		if err == nil { return t, nil }
+	}
+	return time.Time{}, errors.New("Unrecognized date format: \"" + input + "\"")
+}
+
+// Parses a Unix timestamp. When unitsPerSecond is 0, the unit is guessed from the
+// magnitude of the number: seconds, milliseconds, microseconds or nanoseconds.
+func parseEpoch(input string, unitsPerSecond int64) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	n, err := strconv.ParseInt(input, 10, 64)
+	if err != nil {
+		f, err := strconv.ParseFloat(input, 64)
+		if err != nil { return time.Time{}, err }
+		if unitsPerSecond == 0 { unitsPerSecond = epochUnitsFromMagnitude(f) }
+		seconds, fraction := math.Modf(f / float64(unitsPerSecond))
+		return time.Unix(int64(seconds), int64(fraction * 1e9)).UTC(), nil
+	}
+	if unitsPerSecond == 0 { unitsPerSecond = epochUnitsFromMagnitude(float64(n)) }
+	nanosPerUnit := int64(1e9) / unitsPerSecond
+	return time.Unix(n / unitsPerSecond, (n % unitsPerSecond) * nanosPerUnit).UTC(), nil
+}
+
+func epochUnitsFromMagnitude(n float64) int64 {
+	n = math.Abs(n)
+	if n < 1e11 { return 1 }
+	if n < 1e14 { return 1e3 }
+	if n < 1e17 { return 1e6 }
+	return 1e9
+}
+
+func formatEpoch(t time.Time, unitsPerSecond int64) string {
+	if unitsPerSecond == 1e9 { return strconv.FormatInt(t.UnixNano(), 10) }
+	return strconv.FormatInt(t.Unix() * unitsPerSecond + int64(t.Nanosecond()) / (1e9 / unitsPerSecond), 10)
+}
+
+func epochUnit(unit string, name string, unitsPerSecond int64) timeUnit {
+	return timeUnit{
+		unit, name,
+		func(input string, location *time.Location, layout string) (time.Time, error) {
+			return parseEpoch(input, unitsPerSecond)
+		},
+		func(t time.Time, layout string) (string, error) {
+			if unitsPerSecond == 0 { return formatEpoch(t, 1), nil }
+			return formatEpoch(t, unitsPerSecond), nil
+		},
+	}
+}
+
+func layoutUnit(unit string, name string, outputLayout string, inputLayouts []string) timeUnit {
+	return timeUnit{
+		unit, name,
+		func(input string, location *time.Location, layout string) (time.Time, error) {
+			return parseWithLayouts(input, inputLayouts, location)
+		},
+		func(t time.Time, layout string) (string, error) {
+			return t.Format(outputLayout), nil
+		},
+	}
+}
+
+var timeUnits = []timeUnit{
+	epochUnit("epoch", "Unix Time (seconds, or guessed from the magnitude of the input)", 0),
+	epochUnit("epochms", "Unix Time in Milliseconds", 1e3),
+	epochUnit("epochus", "Unix Time in Microseconds", 1e6),
+	epochUnit("epochns", "Unix Time in Nanoseconds", 1e9),
+	layoutUnit("iso", "ISO 8601", time.RFC3339Nano, isoLayouts),
+	layoutUnit("rfc3339", "RFC 3339", time.RFC3339Nano, []string{ time.RFC3339Nano }),
+	layoutUnit("rfc1123", "RFC 1123", time.RFC1123Z, []string{ time.RFC1123Z, time.RFC1123 }),
+	timeUnit{
+		"custom", "Custom Layout (set with --layout, using the Go reference time \"2006-01-02 15:04:05\")",
+		func(input string, location *time.Location, layout string) (time.Time, error) {
+			if layout == "" { return time.Time{}, errors.New("No layout specified, eg. --layout \"02/01/2006 15:04\"") }
+			return time.ParseInLocation(layout, strings.TrimSpace(input), location)
+		},
+		func(t time.Time, layout string) (string, error) {
+			if layout == "" { return "", errors.New("No layout specified, eg. --layout \"02/01/2006 15:04\"") }
+			return t.Format(layout), nil
+		},
+	},
+}
+
+func findTimeUnit(unit string) (timeUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range timeUnits {
+		if u.unit == unit { return u, true }
+	}
+	return timeUnit{}, false
+}
+
+func (this *Conversions) timeLocation() (*time.Location, error) {
+	return loadLocation(this.option("tz", "UTC"))
+}
+
+func addTimeConversions(output *Conversions) {
+	timeConv := func(input string, from timeUnit, to timeUnit) (string, error) {
+		location, err := output.timeLocation()
+		if err != nil { return "", err }
+		layout := output.option("layout", "")
+		t, err := from.parse(input, location, layout)
+		if err != nil { return "", err }
+		return to.format(t.In(location), layout)
+	}
+
+	for _, from := range timeUnits {
+		for _, to := range timeUnits {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"time", from.unit, to.unit, func(input string) (string, error) {
+					return timeConv(input, from, to)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package conversions
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A duration that may contain calendar months (a year being 12 months), whose length
+// depends on the date they are counted from.
+type calendarDuration struct {
+	months float64
+	exact time.Duration
+}
+
+// Calendar months can only be converted to an exact duration relative to an anchor date.
+type durationContext struct {
+	anchor time.Time
+	hasAnchor bool
+}
+
+func (this durationContext) requireAnchor() error {
+	if this.hasAnchor { return nil }
+	return errors.New("Durations in months or years need an anchor date, eg. --anchor 2026-01-31")
+}
+
+var errDurationOutOfRange = errors.New("Duration out of range, it must be shorter than about 292 years")
+
+// Converts a number of nanoseconds to a duration, which must fit in an int64
+func toDuration(ns float64) (time.Duration, error) {
+	if math.IsNaN(ns) || ns >= math.MaxInt64 || ns < math.MinInt64 { return 0, errDurationOutOfRange }
+	return time.Duration(ns), nil
+}
+
+func (this durationContext) resolve(d calendarDuration) (time.Duration, error) {
+	if d.months == 0 { return d.exact, nil }
+	if err := this.requireAnchor(); err != nil { return 0, err }
+	// More months than a duration can hold would overflow the dates
+	if math.Abs(d.months) > 12 * 300 { return 0, errDurationOutOfRange }
+	whole, fraction := math.Modf(d.months)
+	start := this.anchor.AddDate(0, int(whole), 0)
+	output := float64(start.Sub(this.anchor)) + float64(d.exact)
+	if fraction != 0 {
+		step := 1
+		if fraction < 0 { step = -1 }
+		next := this.anchor.AddDate(0, int(whole) + step, 0)
+		output += math.Abs(fraction) * float64(next.Sub(start))
+	}
+	return toDuration(output)
+}
+
+// Returns how many units of the given number of months fit in the duration, counted
+// from the anchor date.
+func (this durationContext) countMonths(d time.Duration, monthsPerUnit int) (float64, error) {
+	if err := this.requireAnchor(); err != nil { return 0, err }
+	end := this.anchor.Add(d)
+	step := 1
+	if d < 0 { step = -1 }
+	n := 0
+	for {
+		next := this.anchor.AddDate(0, (n + step) * monthsPerUnit, 0)
+		if (step > 0 && next.After(end)) || (step < 0 && next.Before(end)) { break }
+		n += step
+	}
+	start := this.anchor.AddDate(0, n * monthsPerUnit, 0)
+	next := this.anchor.AddDate(0, (n + step) * monthsPerUnit, 0)
+	return float64(n) + float64(step) * float64(end.Sub(start)) / float64(next.Sub(start)), nil
+}
+
+type durationUnit struct {
+	unit string
+	name string
+	parse func(input string) (calendarDuration, error)
+	format func(d calendarDuration, context durationContext) (string, error)
+}
+
+func fixedDurationUnit(unit string, name string, length time.Duration) durationUnit {
+	return durationUnit{
+		unit, name,
+		func(input string) (calendarDuration, error) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+			if err != nil { return calendarDuration{}, err }
+			exact, err := toDuration(n * float64(length))
+			if err != nil { return calendarDuration{}, err }
+			return calendarDuration{ 0, exact }, nil
+		},
+		func(d calendarDuration, context durationContext) (string, error) {
+			exact, err := context.resolve(d)
+			if err != nil { return "", err }
+			return formatFloat(float64(exact) / float64(length)), nil
+		},
+	}
+}
+
+func calendarDurationUnit(unit string, name string, monthsPerUnit int) durationUnit {
+	return durationUnit{
+		unit, name,
+		func(input string) (calendarDuration, error) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+			if err != nil { return calendarDuration{}, err }
+			if math.IsNaN(n) || math.IsInf(n, 0) { return calendarDuration{}, errDurationOutOfRange }
+			return calendarDuration{ n * float64(monthsPerUnit), 0 }, nil
+		},
+		func(d calendarDuration, context durationContext) (string, error) {
+			// Months and years are converted between each other without an anchor
+			if d.exact == 0 { return formatFloat(d.months / float64(monthsPerUnit)), nil }
+			exact, err := context.resolve(d)
+			if err != nil { return "", err }
+			n, err := context.countMonths(exact, monthsPerUnit)
+			if err != nil { return "", err }
+			return formatFloat(n), nil
+		},
+	}
+}
+
+// Parses an ISO 8601 duration such as "P1Y2M3DT4H5M6.5S" or "PT1H30M"
+func parseIsoDuration(input string) (calendarDuration, error) {
+	s := strings.ToUpper(strings.TrimSpace(input))
+	invalid := errors.New("Invalid ISO 8601 duration: \"" + input + "\"")
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 { return calendarDuration{}, invalid }
+	s = s[1:]
+	var output calendarDuration
+	// The exact part, in nanoseconds
+	exact := 0.0
+	inTime := false
+	number := ""
+	for _, c := range s {
+		if c == 'T' {
+			if inTime || number != "" { return calendarDuration{}, invalid }
+			inTime = true
+			continue
+		}
+		if unicode.IsDigit(c) || c == '.' || c == ',' {
+			if c == ',' { c = '.' }
+			number += string(c)
+			continue
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil { return calendarDuration{}, invalid }
+		number = ""
+		switch {
+			case c == 'Y' && !inTime: output.months += n * 12
+			case c == 'M' && !inTime: output.months += n
+			case c == 'W' && !inTime: exact += n * float64(7 * 24 * time.Hour)
+			case c == 'D' && !inTime: exact += n * float64(24 * time.Hour)
+			case c == 'H' && inTime: exact += n * float64(time.Hour)
+			case c == 'M' && inTime: exact += n * float64(time.Minute)
+			case c == 'S' && inTime: exact += n * float64(time.Second)
+			default: return calendarDuration{}, invalid
+		}
+	}
+	if number != "" { return calendarDuration{}, invalid }
+	output.months *= sign
+	var err error
+	output.exact, err = toDuration(sign * exact)
+	if err != nil { return calendarDuration{}, err }
+	return output, nil
+}
+
+func formatIsoDuration(d calendarDuration) string {
+	output := ""
+	if d.months < 0 || (d.months == 0 && d.exact < 0) {
+		output = "-"
+		d.months = -d.months
+		d.exact = -d.exact
+	}
+	output += "P"
+	years := math.Floor(d.months / 12)
+	months := d.months - years * 12
+	if years != 0 { output += formatFloat(years) + "Y" }
+	if months != 0 { output += formatFloat(months) + "M" }
+	days := d.exact / (24 * time.Hour)
+	rest := d.exact - days * 24 * time.Hour
+	if days != 0 { output += strconv.FormatInt(int64(days), 10) + "D" }
+	if rest != 0 || output == "P" {
+		output += "T"
+		hours := rest / time.Hour
+		minutes := (rest % time.Hour) / time.Minute
+		seconds := float64(rest % time.Minute) / float64(time.Second)
+		if hours != 0 { output += strconv.FormatInt(int64(hours), 10) + "H" }
+		if minutes != 0 { output += strconv.FormatInt(int64(minutes), 10) + "M" }
+		if seconds != 0 || (hours == 0 && minutes == 0) { output += formatFloat(seconds) + "S" }
+	}
+	return output
+}
+
+// Parses "hh:mm:ss.fff", "mm:ss" or "hh:mm:ss"
+func parseClockDuration(input string) (calendarDuration, error) {
+	s := strings.TrimSpace(input)
+	invalid := errors.New("Invalid duration, expected hh:mm:ss.fff: \"" + input + "\"")
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	tokens := strings.Split(s, ":")
+	if len(tokens) < 2 || len(tokens) > 3 { return calendarDuration{}, invalid }
+	output := 0.0
+	unit := time.Second
+	for i := len(tokens) - 1; i >= 0; i-- {
+		n, err := strconv.ParseFloat(tokens[i], 64)
+		if err != nil || n < 0 { return calendarDuration{}, invalid }
+		// Only the seconds can have a fractional part
+		if i < len(tokens) - 1 && n != math.Floor(n) { return calendarDuration{}, invalid }
+		output += n * float64(unit)
+		unit *= 60
+	}
+	exact, err := toDuration(output)
+	if err != nil { return calendarDuration{}, err }
+	return calendarDuration{ 0, sign * exact }, nil
+}
+
+func formatClockDuration(d time.Duration) string {
+	output := ""
+	if d < 0 {
+		output = "-"
+		d = -d
+	}
+	d = (d + time.Millisecond / 2) / time.Millisecond * time.Millisecond
+	hours := d / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+	millis := (d % time.Second) / time.Millisecond
+	output += leftPad(strconv.FormatInt(int64(hours), 10), 2, '0') + ":"
+	output += leftPad(strconv.FormatInt(int64(minutes), 10), 2, '0') + ":"
+	output += leftPad(strconv.FormatInt(int64(seconds), 10), 2, '0') + "."
+	output += leftPad(strconv.FormatInt(int64(millis), 10), 3, '0')
+	return output
+}
+
+type textDurationUnit struct {
+	names []string
+	singular string
+	plural string
+	length time.Duration // 0 for calendar units
+	months float64
+}
+
+var textDurationUnits = []textDurationUnit{
+	{ []string{ "y", "yr", "yrs", "year", "years" }, "year", "years", 0, 12 },
+	{ []string{ "mo", "mos", "month", "months" }, "month", "months", 0, 1 },
+	{ []string{ "w", "wk", "wks", "week", "weeks" }, "week", "weeks", 7 * 24 * time.Hour, 0 },
+	{ []string{ "d", "day", "days" }, "day", "days", 24 * time.Hour, 0 },
+	{ []string{ "h", "hr", "hrs", "hour", "hours" }, "hour", "hours", time.Hour, 0 },
+	{ []string{ "m", "min", "mins", "minute", "minutes" }, "minute", "minutes", time.Minute, 0 },
+	{ []string{ "s", "sec", "secs", "second", "seconds" }, "second", "seconds", time.Second, 0 },
+	{ []string{ "ms", "msec", "msecs", "millisecond", "milliseconds" }, "millisecond", "milliseconds", time.Millisecond, 0 },
+	{ []string{ "us", "µs", "microsecond", "microseconds" }, "microsecond", "microseconds", time.Microsecond, 0 },
+	{ []string{ "ns", "nanosecond", "nanoseconds" }, "nanosecond", "nanoseconds", time.Nanosecond, 0 },
+}
+
+// Parses human text such as "1 day 3 hours", "2 weeks, 3 days and 4.5 minutes" or "1d 3h"
+func parseTextDuration(input string) (calendarDuration, error) {
+	s := strings.ToLower(input)
+	s = strings.Replace(s, ",", " ", -1)
+	// Split numbers from units so that "1d3h" and "1 d 3 h" are parsed the same way
+	spaced := ""
+	for i, c := range s {
+		if i > 0 {
+			previous := rune(s[i - 1])
+			isNumber := unicode.IsDigit(c) || c == '.'
+			wasNumber := unicode.IsDigit(previous) || previous == '.'
+			if isNumber != wasNumber { spaced += " " }
+		}
+		spaced += string(c)
+	}
+	var output calendarDuration
+	// The exact part, in nanoseconds
+	exact := 0.0
+	tokens := strings.Fields(spaced)
+	sign := 1.0
+	if len(tokens) > 0 && tokens[0] == "-" {
+		sign = -1
+		tokens = tokens[1:]
+	}
+	found := false
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == "and" { continue }
+		n, err := strconv.ParseFloat(tokens[i], 64)
+		if err != nil || i + 1 >= len(tokens) { return calendarDuration{}, errors.New("Invalid duration: \"" + input + "\"") }
+		i++
+		unitFound := false
+		for _, u := range textDurationUnits {
+			for _, name := range u.names {
+				if name != tokens[i] { continue }
+				output.months += n * u.months
+				exact += n * float64(u.length)
+				unitFound = true
+			}
+		}
+		if !unitFound { return calendarDuration{}, errors.New("Unknown duration unit: \"" + tokens[i] + "\"") }
+		found = true
+	}
+	if !found { return calendarDuration{}, errors.New("Invalid duration: \"" + input + "\"") }
+	output.months *= sign
+	var err error
+	output.exact, err = toDuration(sign * exact)
+	if err != nil { return calendarDuration{}, err }
+	return output, nil
+}
+
+func formatTextDuration(d calendarDuration) string {
+	var parts []string
+	negative := d.months < 0 || (d.months == 0 && d.exact < 0)
+	if negative {
+		d.months = -d.months
+		d.exact = -d.exact
+	}
+	addPart := func(n float64, u textDurationUnit) {
+		if n == 0 { return }
+		if n == 1 {
+			parts = append(parts, "1 " + u.singular)
+		} else {
+			parts = append(parts, formatFloat(n) + " " + u.plural)
+		}
+	}
+	years := math.Floor(d.months / 12)
+	addPart(years, textDurationUnits[0])
+	addPart(d.months - years * 12, textDurationUnits[1])
+	rest := d.exact
+	for _, u := range textDurationUnits[3:7] {
+		n := rest / u.length
+		if u.length == time.Second {
+			addPart(float64(rest) / float64(time.Second), u)
+			break
+		}
+		addPart(float64(n), u)
+		rest -= n * u.length
+	}
+	if len(parts) == 0 { return "0 seconds" }
+	output := strings.Join(parts, " ")
+	if negative { output = "-" + output }
+	return output
+}
+
+var durationUnits = []durationUnit{
+	fixedDurationUnit("sec", "Seconds", time.Second),
+	fixedDurationUnit("msec", "Milliseconds", time.Millisecond),
+	fixedDurationUnit("min", "Minutes", time.Minute),
+	fixedDurationUnit("hour", "Hours", time.Hour),
+	fixedDurationUnit("day", "Days (of 24 hours)", 24 * time.Hour),
+	fixedDurationUnit("week", "Weeks", 7 * 24 * time.Hour),
+	calendarDurationUnit("month", "Calendar Months (requires --anchor)", 1),
+	calendarDurationUnit("year", "Calendar Years (requires --anchor)", 12),
+	durationUnit{
+		"go", "Go Duration, eg. 1h30m",
+		func(input string) (calendarDuration, error) {
+			d, err := time.ParseDuration(strings.TrimSpace(input))
+			if err != nil { return calendarDuration{}, err }
+			return calendarDuration{ 0, d }, nil
+		},
+		func(d calendarDuration, context durationContext) (string, error) {
+			exact, err := context.resolve(d)
+			if err != nil { return "", err }
+			return exact.String(), nil
+		},
+	},
+	durationUnit{
+		"isodur", "ISO 8601 Duration, eg. PT1H30M",
+		parseIsoDuration,
+		func(d calendarDuration, context durationContext) (string, error) {
+			return formatIsoDuration(d), nil
+		},
+	},
+	durationUnit{
+		"clock", "Clock Duration, eg. 01:30:00.000",
+		parseClockDuration,
+		func(d calendarDuration, context durationContext) (string, error) {
+			exact, err := context.resolve(d)
+			if err != nil { return "", err }
+			return formatClockDuration(exact), nil
+		},
+	},
+	durationUnit{
+		"text", "Human Text, eg. \"1 day 3 hours\"",
+		parseTextDuration,
+		func(d calendarDuration, context durationContext) (string, error) {
+			return formatTextDuration(d), nil
+		},
+	},
+}
+
+func findDurationUnit(unit string) (durationUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range durationUnits {
+		if u.unit == unit { return u, true }
+	}
+	return durationUnit{}, false
+}
+
+func addDurationConversions(output *Conversions) {
+	durationConv := func(input string, from durationUnit, to durationUnit) (string, error) {
+		d, err := from.parse(input)
+		if err != nil { return "", err }
+		var context durationContext
+		anchor := output.option("anchor", "")
+		if anchor != "" {
+			location, err := output.timeLocation()
+			if err != nil { return "", err }
+			context.anchor, err = parseWithLayouts(anchor, isoLayouts, location)
+			if err != nil { return "", err }
+			context.hasAnchor = true
+		}
+		return to.format(d, context)
+	}
+
+	for _, from := range durationUnits {
+		for _, to := range durationUnits {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"duration", from.unit, to.unit, func(input string) (string, error) {
+					return durationConv(input, from, to)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package conversions
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+)
+
+type hashAlgorithm struct {
+	unit string
+	name string
+	new func() hash.Hash
+}
+
+var hashAlgorithms = []hashAlgorithm{
+	{ "md5", "MD5 Digest", md5.New },
+	{ "sha1", "SHA-1 Digest", sha1.New },
+	{ "sha256", "SHA-256 Digest", sha256.New },
+	{ "sha512", "SHA-512 Digest", sha512.New },
+	{ "blake2b", "BLAKE2b-512 Digest", newBlake2b },
+	{ "crc32", "CRC-32 Checksum", func() hash.Hash { return crc32.NewIEEE() } },
+	{ "adler32", "Adler-32 Checksum", func() hash.Hash { return adler32.New() } },
+	{ "xxhash", "XXH64 Hash", newXxhash64 },
+}
+
+func findHashAlgorithm(unit string) (hashAlgorithm, bool) {
+	unit = strings.ToLower(unit)
+	for _, a := range hashAlgorithms {
+		if a.unit == unit { return a, true }
+	}
+	return hashAlgorithm{}, false
+}
+
+// BLAKE2b (RFC 7693), with a 512-bit digest and no key
+
+var blake2bIv = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+// The order of the message words in each round. Rounds 10 and 11 repeat the first two.
+var blake2bSigma = [10][16]byte{
+	{ 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15 },
+	{ 14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3 },
+	{ 11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4 },
+	{ 7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8 },
+	{ 9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13 },
+	{ 2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9 },
+	{ 12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11 },
+	{ 13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10 },
+	{ 6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5 },
+	{ 10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0 },
+}
+
+type blake2b struct {
+	h [8]uint64
+	// Number of bytes compressed so far
+	length uint64
+	block [128]byte
+	n int
+}
+
+func newBlake2b() hash.Hash {
+	output := new(blake2b)
+	output.Reset()
+	return output
+}
+
+func (this *blake2b) Reset() {
+	this.h = blake2bIv
+	// Parameter block: digest size, no key, fanout and depth of 1
+	this.h[0] ^= 0x01010000 ^ 64
+	this.length = 0
+	this.n = 0
+}
+
+func (this *blake2b) Size() int { return 64 }
+
+func (this *blake2b) BlockSize() int { return 128 }
+
+func (this *blake2b) compress(final bool) {
+	var m [16]uint64
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(this.block[i * 8:])
+	}
+	var v [16]uint64
+	copy(v[:8], this.h[:])
+	copy(v[8:], blake2bIv[:])
+	v[12] ^= this.length
+	if final { v[14] = ^v[14] }
+
+	mix := func(a, b, c, d int, x, y uint64) {
+		v[a] += v[b] + x
+		v[d] = bits.RotateLeft64(v[d] ^ v[a], -32)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft64(v[b] ^ v[c], -24)
+		v[a] += v[b] + y
+		v[d] = bits.RotateLeft64(v[d] ^ v[a], -16)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft64(v[b] ^ v[c], -63)
+	}
+	for round := 0; round < 12; round++ {
+		s := &blake2bSigma[round % 10]
+		mix(0, 4, 8, 12, m[s[0]], m[s[1]])
+		mix(1, 5, 9, 13, m[s[2]], m[s[3]])
+		mix(2, 6, 10, 14, m[s[4]], m[s[5]])
+		mix(3, 7, 11, 15, m[s[6]], m[s[7]])
+		mix(0, 5, 10, 15, m[s[8]], m[s[9]])
+		mix(1, 6, 11, 12, m[s[10]], m[s[11]])
+		mix(2, 7, 8, 13, m[s[12]], m[s[13]])
+		mix(3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+	for i := range this.h {
+		this.h[i] ^= v[i] ^ v[i + 8]
+	}
+}
+
+func (this *blake2b) Write(p []byte) (int, error) {
+	size := len(p)
+	for len(p) > 0 {
+		// The last block is compressed differently, so a full block waits for more data
+		if this.n == len(this.block) {
+			this.length += uint64(this.n)
+			this.compress(false)
+			this.n = 0
+		}
+		copied := copy(this.block[this.n:], p)
+		this.n += copied
+		p = p[copied:]
+	}
+	return size, nil
+}
+
+func (this *blake2b) Sum(b []byte) []byte {
+	final := *this
+	for i := final.n; i < len(final.block); i++ {
+		final.block[i] = 0
+	}
+	final.length += uint64(final.n)
+	final.compress(true)
+	var digest [64]byte
+	for i, word := range final.h {
+		binary.LittleEndian.PutUint64(digest[i * 8:], word)
+	}
+	return append(b, digest[:]...)
+}
+
+// XXH64, a fast non-cryptographic hash, with a seed of 0
+
+const (
+	xxhashPrime1 uint64 = 11400714785074694791
+	xxhashPrime2 uint64 = 14029467366897019727
+	xxhashPrime3 uint64 = 1609587929392839161
+	xxhashPrime4 uint64 = 9650029242287828579
+	xxhashPrime5 uint64 = 2870177450012600261
+)
+
+type xxhash64 struct {
+	// The accumulators of the four lanes of each 32-byte stripe
+	v [4]uint64
+	length uint64
+	stripe [32]byte
+	n int
+}
+
+func newXxhash64() hash.Hash {
+	output := new(xxhash64)
+	output.Reset()
+	return output
+}
+
+func (this *xxhash64) Reset() {
+	// The seed plus or minus some primes, which wrap around unlike constants
+	prime1, prime2 := xxhashPrime1, xxhashPrime2
+	this.v = [4]uint64{ prime1 + prime2, prime2, 0, -prime1 }
+	this.length = 0
+	this.n = 0
+}
+
+func (this *xxhash64) Size() int { return 8 }
+
+func (this *xxhash64) BlockSize() int { return 32 }
+
+func xxhashRound(acc uint64, input uint64) uint64 {
+	return bits.RotateLeft64(acc + input * xxhashPrime2, 31) * xxhashPrime1
+}
+
+func (this *xxhash64) Write(p []byte) (int, error) {
+	size := len(p)
+	this.length += uint64(size)
+	for len(p) > 0 {
+		copied := copy(this.stripe[this.n:], p)
+		this.n += copied
+		p = p[copied:]
+		if this.n < len(this.stripe) { break }
+		for i := range this.v {
+			this.v[i] = xxhashRound(this.v[i], binary.LittleEndian.Uint64(this.stripe[i * 8:]))
+		}
+		this.n = 0
+	}
+	return size, nil
+}
+
+func (this *xxhash64) Sum(b []byte) []byte {
+	var h uint64
+	if this.length >= 32 {
+		h = bits.RotateLeft64(this.v[0], 1) + bits.RotateLeft64(this.v[1], 7) + bits.RotateLeft64(this.v[2], 12) + bits.RotateLeft64(this.v[3], 18)
+		for _, v := range this.v {
+			h = (h ^ xxhashRound(0, v)) * xxhashPrime1 + xxhashPrime4
+		}
+	} else {
+		h = xxhashPrime5
+	}
+	h += this.length
+
+	rest := this.stripe[:this.n]
+	for ; len(rest) >= 8; rest = rest[8:] {
+		h ^= xxhashRound(0, binary.LittleEndian.Uint64(rest))
+		h = bits.RotateLeft64(h, 27) * xxhashPrime1 + xxhashPrime4
+	}
+	if len(rest) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(rest)) * xxhashPrime1
+		h = bits.RotateLeft64(h, 23) * xxhashPrime2 + xxhashPrime3
+		rest = rest[4:]
+	}
+	for _, c := range rest {
+		h ^= uint64(c) * xxhashPrime5
+		h = bits.RotateLeft64(h, 11) * xxhashPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxhashPrime2
+	h ^= h >> 29
+	h *= xxhashPrime3
+	h ^= h >> 32
+	var digest [8]byte
+	binary.BigEndian.PutUint64(digest[:], h)
+	return append(b, digest[:]...)
+}
+
+// The error of a digest that differs from the one expected with the "check" option
+type DigestMismatchError struct {
+	expected string
+	actual string
+}
+
+func (this DigestMismatchError) Error() string {
+	return "Digest mismatch: expected " + this.expected + ", got " + this.actual
+}
+
+// Formats a digest in hexadecimal or base64, depending on the "digest-format" option. With
+// the "check" option, the digest is compared with the expected one, in either format.
+func (this *Conversions) formatDigest(sum []byte) (string, error) {
+	var output string
+	switch format := strings.ToLower(this.option("digest-format", "hex")); format {
+		case "hex": output = hex.EncodeToString(sum)
+		case "base64": output = base64.StdEncoding.EncodeToString(sum)
+		default: return "", errors.New("Invalid value for option \"digest-format\": " + format)
+	}
+
+	expected := strings.TrimSpace(this.option("check", ""))
+	if expected == "" { return output, nil }
+	encodings := []string{
+		hex.EncodeToString(sum),
+		base64.StdEncoding.EncodeToString(sum),
+		base64.RawStdEncoding.EncodeToString(sum),
+		base64.URLEncoding.EncodeToString(sum),
+		base64.RawURLEncoding.EncodeToString(sum),
+	}
+	for i, s := range encodings {
+		if s == expected || (i == 0 && strings.ToLower(expected) == s) { return output, nil }
+	}
+	return "", DigestMismatchError{ expected, output }
+}
+
+// Digests are one-way conversions from text, or from files, which are read from the path
+// given as the value or streamed from --input or stdin
+func addHashConversions(output *Conversions) {
+	for _, algorithm := range hashAlgorithms {
+		algorithm := algorithm
+		digest := func(input io.Reader, w io.Writer) error {
+			h := algorithm.new()
+			if _, err := io.Copy(h, input); err != nil { return err }
+			s, err := output.formatDigest(h.Sum(nil))
+			if err != nil { return err }
+			_, err = io.WriteString(w, s + "\n")
+			return err
+		}
+
+		output.AddLineStream(StreamConversion{ "hash", "text", algorithm.unit, digest })
+
+		output.streams = append(output.streams, StreamConversion{ "hash", "file", algorithm.unit, digest })
+		output.Add(Conversion{
+			"hash", "file", algorithm.unit, func(input string) (string, error) {
+				file, err := os.Open(input)
+				if err != nil { return "", err }
+				defer file.Close()
+				var s strings.Builder
+				if err := digest(file, &s); err != nil { return "", err }
+				return strings.TrimSuffix(s.String(), "\n"), nil
+			},
+		})
+
+		output.markOneWay("hash", algorithm.unit)
+	}
+}
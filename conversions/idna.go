@@ -0,0 +1,262 @@
+package conversions
+
+import (
+	"compress/gzip"
+	"embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Tables of the UTS #46 mapping, IDNA 2008 and NFC. See idna/generate.py for their format.
+//go:embed idna/*.bin.gz
+var idnaTables embed.FS
+
+const (
+	idnaDisallowed = 0
+	idnaValid = 1
+	idnaMapped = 2
+	idnaIgnored = 3
+	idnaContextJ = 4
+	idnaContextO = 5
+)
+
+type idnaRun struct {
+	start rune
+	status byte
+	mapping []rune
+}
+
+type idnaData struct {
+	runs []idnaRun
+	joiningTypes map[rune]byte
+	decompositions map[rune][2]rune
+	compositions map[[2]rune]rune
+	combiningClasses map[rune]byte
+}
+
+var idnaDataOnce sync.Once
+var loadedIdnaData *idnaData
+var idnaDataErr error
+
+func readIdnaTable(name string) ([]byte, error) {
+	file, err := idnaTables.Open("idna/" + name + ".bin.gz")
+	if err != nil { return nil, err }
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil { return nil, err }
+	return ioutil.ReadAll(reader)
+}
+
+func loadIdnaData() (*idnaData, error) {
+	idnaDataOnce.Do(func() {
+		output := &idnaData{ nil, make(map[rune]byte), make(map[rune][2]rune), make(map[[2]rune]rune), make(map[rune]byte) }
+		tables := map[string][]byte{}
+		for _, name := range []string{ "uts46", "joining", "decompositions", "combining" } {
+			tables[name], idnaDataErr = readIdnaTable(name)
+			if idnaDataErr != nil { return }
+		}
+
+		data := tables["uts46"]
+		for i := 0; i + 6 <= len(data); {
+			run := idnaRun{ rune(binary.BigEndian.Uint32(data[i:])), data[i + 4], nil }
+			length := int(data[i + 5])
+			i += 6
+			for j := 0; j < length && i + 4 <= len(data); j++ {
+				run.mapping = append(run.mapping, rune(binary.BigEndian.Uint32(data[i:])))
+				i += 4
+			}
+			output.runs = append(output.runs, run)
+		}
+		data = tables["joining"]
+		for i := 0; i + 5 <= len(data); i += 5 {
+			output.joiningTypes[rune(binary.BigEndian.Uint32(data[i:]))] = data[i + 4]
+		}
+		data = tables["decompositions"]
+		for i := 0; i + 13 <= len(data); i += 13 {
+			r := rune(binary.BigEndian.Uint32(data[i:]))
+			pair := [2]rune{ rune(binary.BigEndian.Uint32(data[i + 4:])), rune(binary.BigEndian.Uint32(data[i + 8:])) }
+			output.decompositions[r] = pair
+			if data[i + 12] != 0 { output.compositions[pair] = r }
+		}
+		data = tables["combining"]
+		for i := 0; i + 5 <= len(data); i += 5 {
+			output.combiningClasses[rune(binary.BigEndian.Uint32(data[i:]))] = data[i + 4]
+		}
+		loadedIdnaData = output
+	})
+	return loadedIdnaData, idnaDataErr
+}
+
+// Returns the status of a code point and, if it is mapped, what it is mapped to. ASCII
+// isn't in the table, and is handled by the hyphen and letter rules of the labels.
+func (this *idnaData) status(r rune) (byte, []rune) {
+	if r < 0x80 { return idnaValid, nil }
+	i := sort.Search(len(this.runs), func(i int) bool { return this.runs[i].start > r }) - 1
+	if i < 0 { return idnaDisallowed, nil }
+	return this.runs[i].status, this.runs[i].mapping
+}
+
+// Hangul syllables, which are composed and decomposed algorithmically
+const (
+	hangulSBase = 0xac00
+	hangulLBase = 0x1100
+	hangulVBase = 0x1161
+	hangulTBase = 0x11a7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulSCount = hangulLCount * hangulVCount * hangulTCount
+)
+
+func (this *idnaData) decompose(r rune, output []rune) []rune {
+	if r >= hangulSBase && r < hangulSBase + hangulSCount {
+		index := r - hangulSBase
+		output = append(output, hangulLBase + index / (hangulVCount * hangulTCount), hangulVBase + index % (hangulVCount * hangulTCount) / hangulTCount)
+		if t := index % hangulTCount; t != 0 { output = append(output, hangulTBase + t) }
+		return output
+	}
+	pair, exists := this.decompositions[r]
+	if !exists { return append(output, r) }
+	output = this.decompose(pair[0], output)
+	if pair[1] != 0 { output = this.decompose(pair[1], output) }
+	return output
+}
+
+func (this *idnaData) compose(a rune, b rune) (rune, bool) {
+	if a >= hangulLBase && a < hangulLBase + hangulLCount && b >= hangulVBase && b < hangulVBase + hangulVCount {
+		return hangulSBase + ((a - hangulLBase) * hangulVCount + b - hangulVBase) * hangulTCount, true
+	}
+	if a >= hangulSBase && a < hangulSBase + hangulSCount && (a - hangulSBase) % hangulTCount == 0 && b > hangulTBase && b < hangulTBase + hangulTCount {
+		return a + b - hangulTBase, true
+	}
+	output, exists := this.compositions[[2]rune{ a, b }]
+	return output, exists
+}
+
+// Normalises a string to NFC: its canonical decomposition, with the combining marks in
+// canonical order, is composed back.
+func (this *idnaData) nfc(s string) string {
+	var decomposed []rune
+	for _, r := range s {
+		decomposed = this.decompose(r, decomposed)
+	}
+	for i := 1; i < len(decomposed); i++ {
+		for j := i; j > 0; j-- {
+			class := this.combiningClasses[decomposed[j]]
+			if class == 0 || this.combiningClasses[decomposed[j - 1]] <= class { break }
+			decomposed[j - 1], decomposed[j] = decomposed[j], decomposed[j - 1]
+		}
+	}
+
+	var output []rune
+	starter := -1
+	// The combining class of the last character after the starter, or -1 if there is none
+	lastClass := -1
+	for _, r := range decomposed {
+		class := int(this.combiningClasses[r])
+		// A character is blocked from the starter by a character of the same or a higher class
+		blocked := lastClass != -1 && (lastClass == 0 || lastClass >= class)
+		if starter >= 0 && !blocked {
+			if composed, ok := this.compose(output[starter], r); ok {
+				output[starter] = composed
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(output)
+			lastClass = -1
+		} else {
+			lastClass = class
+		}
+		output = append(output, r)
+	}
+	return string(output)
+}
+
+// Maps a domain name with the UTS #46 table, which lowercases it, changes full-width
+// characters to their usual form and removes the characters that are ignored, then
+// normalises it to NFC.
+func mapDomain(domain string) (string, error) {
+	data, err := loadIdnaData()
+	if err != nil { return "", err }
+	var output strings.Builder
+	for _, r := range domain {
+		status, mapping := data.status(r)
+		switch {
+			case r >= 'A' && r <= 'Z': output.WriteRune(unicode.ToLower(r))
+			case status == idnaMapped: output.WriteString(string(mapping))
+			case status == idnaIgnored:
+			default: output.WriteRune(r)
+		}
+	}
+	return data.nfc(output.String()), nil
+}
+
+func hasRuneIn(runes []rune, table *unicode.RangeTable) bool {
+	for _, r := range runes {
+		if unicode.Is(table, r) { return true }
+	}
+	return false
+}
+
+func hasRuneBetween(runes []rune, low rune, high rune) bool {
+	for _, r := range runes {
+		if r >= low && r <= high { return true }
+	}
+	return false
+}
+
+// Checks the context rules of RFC 5892 for the character at the given index
+func (this *idnaData) validContext(runes []rune, i int) bool {
+	var before, after rune = -1, -1
+	if i > 0 { before = runes[i - 1] }
+	if i + 1 < len(runes) { after = runes[i + 1] }
+	switch r := runes[i]; {
+		case r == 0x200c:
+			if before >= 0 && this.combiningClasses[before] == 9 { return true }
+			// Otherwise, it must be between characters that join, with transparent ones between them
+			j := i - 1
+			for j >= 0 && this.joiningTypes[runes[j]] == 'T' { j-- }
+			if j < 0 || (this.joiningTypes[runes[j]] != 'L' && this.joiningTypes[runes[j]] != 'D') { return false }
+			j = i + 1
+			for j < len(runes) && this.joiningTypes[runes[j]] == 'T' { j++ }
+			return j < len(runes) && (this.joiningTypes[runes[j]] == 'R' || this.joiningTypes[runes[j]] == 'D')
+		case r == 0x200d:
+			return before >= 0 && this.combiningClasses[before] == 9
+		case r == 0x00b7:
+			return before == 'l' && after == 'l'
+		case r == 0x0375:
+			return after >= 0 && unicode.Is(unicode.Greek, after)
+		case r == 0x05f3 || r == 0x05f4:
+			return before >= 0 && unicode.Is(unicode.Hebrew, before)
+		case r == 0x30fb:
+			return hasRuneIn(runes, unicode.Hiragana) || hasRuneIn(runes, unicode.Katakana) || hasRuneIn(runes, unicode.Han)
+		case r >= 0x0660 && r <= 0x0669:
+			// Arabic-Indic digits can't be mixed with the extended ones, and vice versa
+			return !hasRuneBetween(runes, 0x06f0, 0x06f9)
+		case r >= 0x06f0 && r <= 0x06f9:
+			return !hasRuneBetween(runes, 0x0660, 0x0669)
+	}
+	return false
+}
+
+// Checks that a label only has code points that IDNA 2008 allows, in NFC and in the
+// contexts they require. The bidi rule of RFC 5893 isn't checked.
+func validateIdnaLabel(label string) error {
+	data, err := loadIdnaData()
+	if err != nil { return err }
+	if data.nfc(label) != label { return errors.New("Label is not in NFC: \"" + label + "\"") }
+	runes := []rune(label)
+	for i, r := range runes {
+		status, _ := data.status(r)
+		valid := status == idnaValid || ((status == idnaContextJ || status == idnaContextO) && data.validContext(runes, i))
+		if !valid { return fmt.Errorf("Disallowed character %U '%c' in label \"%s\"", r, r, label) }
+	}
+	return nil
+}
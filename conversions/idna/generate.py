@@ -0,0 +1,86 @@
+#!/usr/bin/env python3
+# Generates the IDNA tables from Python's unicodedata module and the "idna" package
+# (pip install idna), which has the UTS #46 mapping and the IDNA 2008 derived properties:
+#
+#    cd conversions/idna && python3 generate.py
+#
+# uts46.bin.gz lists the runs of non-ASCII code points that have the same status, each as
+# a big-endian uint32 start, a uint8 status and a uint8 length followed by that many uint32
+# code points that the run is mapped to. Mapped runs are a single code point. The statuses
+# are those of the nontransitional processing of UTS #46, in which deviations are valid,
+# combined with IDNA 2008, so that code points that UTS #46 allows but IDNA 2008 doesn't
+# are disallowed:
+#
+#    0: disallowed, 1: valid, 2: mapped, 3: ignored, 4: valid in context (CONTEXTJ),
+#    5: valid in context (CONTEXTO)
+#
+# joining.bin.gz lists the (code point, joining type) uint32 and uint8 pairs, which the
+# context rule of ZERO WIDTH NON-JOINER needs.
+#
+# decompositions.bin.gz lists the canonical decompositions as (code point, first, second,
+# composes) uint32, uint32, uint32 and uint8 tuples, where second is 0 for singletons
+# and composes is 1 when NFC composes the pair back.
+#
+# combining.bin.gz lists the (code point, canonical combining class) uint32 and uint8
+# pairs of the code points whose class isn't 0.
+
+import bisect
+import gzip
+import struct
+import unicodedata
+
+import idna.idnadata
+import idna.uts46data
+
+def write_table(name, records):
+    with gzip.GzipFile(name, "wb", mtime=0) as f:
+        for record in records:
+            f.write(record)
+
+def in_ranges(ranges, code):
+    # The ranges of the idna package are encoded as start << 32 | end
+    i = bisect.bisect_right(ranges, code << 32 | 0x110000)
+    return i > 0 and ranges[i - 1] >> 32 <= code < ranges[i - 1] & 0xffffffff
+
+uts46 = idna.uts46data.uts46data
+uts46_starts = [row[0] for row in uts46]
+classes = idna.idnadata.codepoint_classes
+
+def status(code):
+    row = uts46[bisect.bisect_right(uts46_starts, code) - 1]
+    if row[1] == "M" or (row[1] == "3" and len(row) > 2 and row[2]):
+        return 2, row[2]
+    if row[1] == "I": return 3, ""
+    if row[1] == "X": return 0, ""
+    if in_ranges(classes["PVALID"], code): return 1, ""
+    if in_ranges(classes["CONTEXTJ"], code): return 4, ""
+    if in_ranges(classes["CONTEXTO"], code): return 5, ""
+    return 0, ""
+
+records = []
+previous = None
+for code in range(0x80, 0x110000):
+    current = status(code)
+    # Mapped code points each have their own run
+    if current == previous and current[0] != 2: continue
+    mapping = [ord(c) for c in current[1]]
+    records.append(struct.pack(">IBB", code, current[0], len(mapping)) + b"".join(struct.pack(">I", c) for c in mapping))
+    previous = current
+write_table("uts46.bin.gz", records)
+
+write_table("joining.bin.gz", [struct.pack(">IB", code, kind) for code, kind in sorted(idna.idnadata.joining_types.items())])
+
+decompositions = []
+combining = []
+for code in range(0x110000):
+    c = chr(code)
+    if unicodedata.combining(c): combining.append(struct.pack(">IB", code, unicodedata.combining(c)))
+    decomposition = unicodedata.decomposition(c)
+    # Hangul syllables are decomposed algorithmically, and compatibility decompositions
+    # start with a tag such as "<compat>"
+    if not decomposition or decomposition.startswith("<"): continue
+    parts = [int(part, 16) for part in decomposition.split()]
+    composes = len(parts) == 2 and unicodedata.normalize("NFC", chr(parts[0]) + chr(parts[1])) == c
+    decompositions.append(struct.pack(">IIIB", code, parts[0], parts[1] if len(parts) == 2 else 0, composes))
+write_table("decompositions.bin.gz", decompositions)
+write_table("combining.bin.gz", combining)
@@ -0,0 +1,255 @@
+package conversions
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var snowflakeEpochs = map[string]int64{
+	"twitter": 1288834974657,
+	"discord": 1420070400000,
+}
+
+// Parses a UUID in canonical, compact, braced or URN form
+func parseUuid(input string) ([16]byte, error) {
+	var output [16]byte
+	s := strings.ToLower(strings.TrimSpace(input))
+	s = strings.TrimPrefix(s, "urn:uuid:")
+	s = strings.TrimPrefix(s, "{")
+	s = strings.TrimSuffix(s, "}")
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' { return output, errors.New("Invalid UUID: \"" + input + "\"") }
+		s = strings.Replace(s, "-", "", -1)
+	}
+	if len(s) != 32 { return output, errors.New("Invalid UUID: \"" + input + "\"") }
+	b, err := hex.DecodeString(s)
+	if err != nil { return output, errors.New("Invalid UUID: \"" + input + "\"") }
+	copy(output[:], b)
+	return output, nil
+}
+
+func formatUuid(u [16]byte) string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+func uuidVersion(u [16]byte) int {
+	return int(u[6] >> 4)
+}
+
+func uuidVariant(u [16]byte) string {
+	switch {
+		case u[8] & 0x80 == 0: return "NCS (reserved)"
+		case u[8] & 0xc0 == 0x80: return "RFC 9562"
+		case u[8] & 0xe0 == 0xc0: return "Microsoft (reserved)"
+	}
+	return "Future (reserved)"
+}
+
+// Number of 100-nanosecond intervals between the start of the Gregorian calendar
+// (15 October 1582) and the Unix epoch.
+const uuidGregorianOffset = 122192928000000000
+
+func uuidTime(u [16]byte) (time.Time, error) {
+	var ticks int64
+	switch uuidVersion(u) {
+		case 1:
+			ticks = int64(binary.BigEndian.Uint16(u[6:8]) & 0x0fff) << 48 | int64(binary.BigEndian.Uint16(u[4:6])) << 32 | int64(binary.BigEndian.Uint32(u[0:4]))
+		case 6:
+			ticks = int64(binary.BigEndian.Uint32(u[0:4])) << 28 | int64(binary.BigEndian.Uint16(u[4:6])) << 12 | int64(binary.BigEndian.Uint16(u[6:8]) & 0x0fff)
+		case 7:
+			ms := int64(binary.BigEndian.Uint64(append([]byte{ 0, 0 }, u[0:6]...)))
+			return time.UnixMilli(ms), nil
+		default:
+			return time.Time{}, errors.New("UUID version " + strconv.Itoa(uuidVersion(u)) + " does not contain a time")
+	}
+	ticks -= uuidGregorianOffset
+	return time.Unix(ticks / 1e7, (ticks % 1e7) * 100), nil
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func crockfordValue(c byte) (int, bool) {
+	c = strings.ToUpper(string(c))[0]
+	switch c {
+		case 'I', 'L': c = '1'
+		case 'O': c = '0'
+	}
+	i := strings.IndexByte(crockfordAlphabet, c)
+	return i, i >= 0
+}
+
+// Decodes a ULID into its 16 bytes: a 48-bit timestamp in milliseconds followed by
+// 80 bits of randomness.
+func parseUlid(input string) ([16]byte, error) {
+	var output [16]byte
+	s := strings.TrimSpace(input)
+	if len(s) != 26 { return output, errors.New("Invalid ULID, expected 26 characters: \"" + input + "\"") }
+	// 26 characters of 5 bits are 130 bits, so the first character is at most 7
+	var hi, lo uint64
+	for i := 0; i < len(s); i++ {
+		v, ok := crockfordValue(s[i])
+		if !ok || (i == 0 && v > 7) { return output, errors.New("Invalid ULID: \"" + input + "\"") }
+		hi = hi << 5 | lo >> 59
+		lo = lo << 5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(output[0:8], hi)
+	binary.BigEndian.PutUint64(output[8:16], lo)
+	return output, nil
+}
+
+func parseObjectId(input string) ([12]byte, error) {
+	var output [12]byte
+	s := strings.TrimSpace(input)
+	s = strings.TrimPrefix(s, "ObjectId(\"")
+	s = strings.TrimSuffix(s, "\")")
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 12 { return output, errors.New("Invalid ObjectId, expected 24 hexadecimal digits: \"" + input + "\"") }
+	copy(output[:], b)
+	return output, nil
+}
+
+func (this *Conversions) snowflakeEpoch() (int64, error) {
+	name := strings.ToLower(this.option("snowflake-epoch", "twitter"))
+	if epoch, ok := snowflakeEpochs[name]; ok { return epoch, nil }
+	epoch, err := strconv.ParseInt(name, 10, 64)
+	if err != nil { return 0, errors.New("Invalid snowflake epoch, expected \"twitter\", \"discord\" or a Unix time in milliseconds: \"" + name + "\"") }
+	return epoch, nil
+}
+
+func parseSnowflake(input string) (uint64, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(input), 10, 64)
+	if err != nil { return 0, errors.New("Invalid snowflake: \"" + input + "\"") }
+	return id, nil
+}
+
+type idField struct {
+	name string
+	value string
+}
+
+func formatIdFields(fields []idField) string {
+	longest := 0
+	for _, f := range fields {
+		if len(f.name) > longest { longest = len(f.name) }
+	}
+	var lines []string
+	for _, f := range fields {
+		lines = append(lines, f.name + ":" + strings.Repeat(" ", longest - len(f.name) + 1) + f.value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The fields of each ID type. The time is always the first field.
+func (this *Conversions) idFields(idType string, input string) ([]idField, time.Time, error) {
+	location, err := this.timeLocation()
+	if err != nil { return nil, time.Time{}, err }
+	formatTime := func(t time.Time) string { return t.In(location).Format(time.RFC3339Nano) }
+
+	switch idType {
+
+		case "snowflake":
+
+			id, err := parseSnowflake(input)
+			if err != nil { return nil, time.Time{}, err }
+			epoch, err := this.snowflakeEpoch()
+			if err != nil { return nil, time.Time{}, err }
+			t := time.UnixMilli(int64(id >> 22) + epoch)
+			return []idField{
+				{ "time", formatTime(t) },
+				{ "datacenter", strconv.FormatUint((id >> 17) & 0x1f, 10) },
+				{ "worker", strconv.FormatUint((id >> 12) & 0x1f, 10) },
+				{ "sequence", strconv.FormatUint(id & 0xfff, 10) },
+			}, t, nil
+
+		case "ulid":
+
+			u, err := parseUlid(input)
+			if err != nil { return nil, time.Time{}, err }
+			t := time.UnixMilli(int64(binary.BigEndian.Uint64(append([]byte{ 0, 0 }, u[0:6]...))))
+			return []idField{
+				{ "time", formatTime(t) },
+				{ "randomness", hex.EncodeToString(u[6:]) },
+				{ "uuid", formatUuid(u) },
+			}, t, nil
+
+		case "uuid":
+
+			u, err := parseUuid(input)
+			if err != nil { return nil, time.Time{}, err }
+			fields := []idField{
+				{ "version", strconv.Itoa(uuidVersion(u)) },
+				{ "variant", uuidVariant(u) },
+			}
+			t, timeErr := uuidTime(u)
+			if timeErr == nil { fields = append([]idField{ { "time", formatTime(t) } }, fields...) }
+			switch uuidVersion(u) {
+				case 1, 6:
+					fields = append(fields, idField{ "clock sequence", strconv.Itoa(int(binary.BigEndian.Uint16(u[8:10]) & 0x3fff)) })
+					node := hex.EncodeToString(u[10:16])
+					nodeType := "MAC address"
+					if u[10] & 0x01 != 0 { nodeType = "random" }
+					fields = append(fields, idField{ "node", node[0:2] + ":" + node[2:4] + ":" + node[4:6] + ":" + node[6:8] + ":" + node[8:10] + ":" + node[10:12] + " (" + nodeType + ")" })
+				case 7:
+					fields = append(fields, idField{ "randomness", hex.EncodeToString(u[6:16]) })
+			}
+			return fields, t, timeErr
+
+		case "objectid":
+
+			id, err := parseObjectId(input)
+			if err != nil { return nil, time.Time{}, err }
+			t := time.Unix(int64(binary.BigEndian.Uint32(id[0:4])), 0)
+			return []idField{
+				{ "time", formatTime(t) },
+				{ "random", hex.EncodeToString(id[4:9]) },
+				{ "counter", strconv.FormatUint(uint64(id[9]) << 16 | uint64(id[10]) << 8 | uint64(id[11]), 10) },
+			}, t, nil
+
+	}
+
+	return nil, time.Time{}, errors.New("Unknown ID type: \"" + idType + "\"")
+}
+
+var idTypes = [][]string{
+	[]string{"snowflake", "Twitter/Discord Snowflake (epoch set with --snowflake-epoch)"},
+	[]string{"ulid", "ULID"},
+	[]string{"uuid", "UUID"},
+	[]string{"objectid", "MongoDB ObjectId"},
+}
+
+func addIdConversions(output *Conversions) {
+	for _, row := range idTypes {
+		idType := row[0]
+
+		output.Add(Conversion{
+			"id", idType, "iso", func(input string) (string, error) {
+				location, err := output.timeLocation()
+				if err != nil { return "", err }
+				_, t, err := output.idFields(idType, input)
+				if err != nil { return "", err }
+				return t.In(location).Format(time.RFC3339Nano), nil
+			},
+		})
+
+		output.Add(Conversion{
+			"id", idType, "epochms", func(input string) (string, error) {
+				_, t, err := output.idFields(idType, input)
+				if err != nil { return "", err }
+				return strconv.FormatInt(t.UnixMilli(), 10), nil
+			},
+		})
+
+		output.Add(Conversion{
+			"id", idType, "fields", func(input string) (string, error) {
+				fields, _, err := output.idFields(idType, input)
+				if fields == nil { return "", err }
+				return formatIdFields(fields), nil
+			},
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package conversions
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// A unit that is either proportional (value = factor * base) or inversely
+// proportional (value = factor / base) to the base unit of its category.
+type inverseUnit struct {
+	unit string
+	name string
+	symbol string
+	factor float64
+	inverse bool
+}
+
+func (this inverseUnit) toBase(value float64) float64 {
+	if this.inverse { return this.factor / value }
+	return value / this.factor
+}
+
+func (this inverseUnit) fromBase(value float64) float64 {
+	if this.inverse { return this.factor / value }
+	return value * this.factor
+}
+
+const kmPerMile = 1.609344
+const litresPerUsGallon = 3.785411784
+const litresPerUkGallon = 4.54609
+
+// Base unit is km/l
+var fuelEconomyUnits = []inverseUnit{
+	{ "kml", "Kilometres per Litre", "km/l", 1, false },
+	{ "l100km", "Litres per 100 Kilometres", "l/100km", 100, true },
+	{ "mpg", "Miles per US Gallon", "mpg", litresPerUsGallon / kmPerMile, false },
+	{ "ukmpg", "Miles per UK Gallon", "UK mpg", litresPerUkGallon / kmPerMile, false },
+	{ "gal100mi", "US Gallons per 100 Miles", "gal/100mi", 100 * kmPerMile / litresPerUsGallon, true },
+}
+
+// Base unit is km/kWh
+var electricEconomyUnits = []inverseUnit{
+	{ "kmkwh", "Kilometres per kWh", "km/kWh", 1, false },
+	{ "kwh100km", "kWh per 100 Kilometres", "kWh/100km", 100, true },
+	{ "mikwh", "Miles per kWh", "mi/kWh", 1 / kmPerMile, false },
+	{ "whkm", "Wh per Kilometre", "Wh/km", 1000, true },
+	{ "whmi", "Wh per Mile", "Wh/mi", 1000 * kmPerMile, true },
+}
+
+// Base unit is km/h
+var paceUnits = []inverseUnit{
+	{ "kmh", "Kilometres per Hour", "km/h", 1, false },
+	{ "mph", "Miles per Hour", "mph", 1 / kmPerMile, false },
+	{ "ms", "Metres per Second", "m/s", 1 / 3.6, false },
+	{ "minkm", "Minutes per Kilometre", "min/km", 60, true },
+	{ "minmi", "Minutes per Mile", "min/mi", 60 * kmPerMile, true },
+}
+
+var inverseCategories = map[string][]inverseUnit{
+	"fuel economy": fuelEconomyUnits,
+	"electric economy": electricEconomyUnits,
+	"pace": paceUnits,
+}
+
+func findInverseUnit(category string, unit string) (inverseUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range inverseCategories[category] {
+		if u.unit == unit { return u, true }
+	}
+	return inverseUnit{}, false
+}
+
+// Parses a pace such as "5:30" (minutes and seconds) or a plain number of minutes.
+func parsePace(input string) (float64, error) {
+	tokens := strings.Split(input, ":")
+	if len(tokens) > 2 { return 0, errors.New("Invalid pace: \"" + input + "\"") }
+	output := 0.0
+	for i, token := range tokens {
+		n, err := strconv.ParseFloat(token, 64)
+		if err != nil { return 0, err }
+		if i == 0 {
+			output = n
+		} else {
+			output += n / 60
+		}
+	}
+	return output, nil
+}
+
+func formatPace(minutes float64) string {
+	seconds := int(minutes * 60 + 0.5)
+	return strconv.Itoa(seconds / 60) + ":" + leftPad(strconv.Itoa(seconds % 60), 2, '0')
+}
+
+func leftPad(s string, length int, c byte) string {
+	for len(s) < length {
+		s = string(c) + s
+	}
+	return s
+}
+
+func addInverseConversions(output *Conversions) {
+	inverseConv := func(input string, from inverseUnit, to inverseUnit) (string, error) {
+		input = strings.TrimSpace(input)
+		var value float64
+		var err error
+		if from.inverse && strings.Contains(input, ":") {
+			value, err = parsePace(input)
+		} else {
+			value, err = strconv.ParseFloat(input, 64)
+		}
+		if err != nil { return "", err }
+		if value <= 0 && (from.inverse || to.inverse) { return "", errors.New("Value must be greater than zero: " + input) }
+		result := to.fromBase(from.toBase(value))
+		if to.inverse && (to.unit == "minkm" || to.unit == "minmi") { return formatPace(result), nil }
+		return formatFloat(result), nil
+	}
+
+	for _, category := range []string{ "fuel economy", "electric economy", "pace" } {
+		units := inverseCategories[category]
+		for _, from := range units {
+			for _, to := range units {
+				category := category
+				from := from
+				to := to
+				output.Add(Conversion{
+					category, from.unit, to.unit, func(input string) (string, error) {
+						return inverseConv(input, from, to)
+					},
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package conversions
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Parses a MAC address in any of the colon, hyphen, Cisco dotted or bare
+// hexadecimal forms.
+func parseMac(input string) ([6]byte, error) {
+	var output [6]byte
+	s := strings.TrimSpace(input)
+	for _, separator := range []string{ ":", "-", ".", " " } {
+		s = strings.Replace(s, separator, "", -1)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 6 { return output, errors.New("Invalid MAC address: \"" + input + "\"") }
+	copy(output[:], b)
+	return output, nil
+}
+
+func joinHexGroups(b []byte, groupSize int, separator string) string {
+	s := hex.EncodeToString(b)
+	var groups []string
+	for i := 0; i < len(s); i += groupSize {
+		groups = append(groups, s[i:i + groupSize])
+	}
+	return strings.Join(groups, separator)
+}
+
+// Modified EUI-64 interface identifier, as used by IPv6 SLAAC (RFC 4291): "fffe" is
+// inserted in the middle and the universal/local bit is inverted.
+func macToEui64(mac [6]byte) [8]byte {
+	return [8]byte{ mac[0] ^ 0x02, mac[1], mac[2], 0xff, 0xfe, mac[3], mac[4], mac[5] }
+}
+
+func eui64ToMac(eui [8]byte) ([6]byte, error) {
+	if eui[3] != 0xff || eui[4] != 0xfe { return [6]byte{}, errors.New("Not derived from a MAC address, expected ff:fe in the middle") }
+	return [6]byte{ eui[0] ^ 0x02, eui[1], eui[2], eui[5], eui[6], eui[7] }, nil
+}
+
+func parseEui64(input string) ([8]byte, error) {
+	var output [8]byte
+	s := strings.TrimSpace(input)
+	for _, separator := range []string{ ":", "-", ".", " " } {
+		s = strings.Replace(s, separator, "", -1)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 8 { return output, errors.New("Invalid EUI-64, expected 16 hexadecimal digits: \"" + input + "\"") }
+	copy(output[:], b)
+	return output, nil
+}
+
+func formatEui64(eui [8]byte) string {
+	return joinHexGroups(eui[:], 4, ":")
+}
+
+type macFormat struct {
+	unit string
+	name string
+	parse func(input string) ([6]byte, error)
+	format func(mac [6]byte) string
+}
+
+var macFormats = []macFormat{
+	{ "mac", "MAC Address, eg. 00:1a:2b:3c:4d:5e", parseMac, func(mac [6]byte) string { return joinHexGroups(mac[:], 2, ":") } },
+	{ "machyphen", "MAC Address with Hyphens, eg. 00-1A-2B-3C-4D-5E", parseMac, func(mac [6]byte) string { return strings.ToUpper(joinHexGroups(mac[:], 2, "-")) } },
+	{ "maccisco", "Cisco Dotted MAC Address, eg. 001a.2b3c.4d5e", parseMac, func(mac [6]byte) string { return joinHexGroups(mac[:], 4, ".") } },
+	{ "machex", "Bare Hexadecimal MAC Address", parseMac, func(mac [6]byte) string { return hex.EncodeToString(mac[:]) } },
+	{
+		"macint", "MAC Address as a 48-bit Integer",
+		func(input string) ([6]byte, error) {
+			var output [6]byte
+			n, err := strconv.ParseUint(strings.TrimSpace(input), 10, 48)
+			if err != nil { return output, errors.New("Invalid 48-bit integer: \"" + input + "\"") }
+			for i := 5; i >= 0; i-- {
+				output[i] = byte(n)
+				n >>= 8
+			}
+			return output, nil
+		},
+		func(mac [6]byte) string {
+			var n uint64
+			for _, b := range mac {
+				n = n << 8 | uint64(b)
+			}
+			return strconv.FormatUint(n, 10)
+		},
+	},
+}
+
+func findMacFormat(unit string) (macFormat, bool) {
+	unit = strings.ToLower(unit)
+	for _, f := range macFormats {
+		if f.unit == unit { return f, true }
+	}
+	return macFormat{}, false
+}
+
+var macUnits = []networkUnit{
+	{ "eui64", "Modified EUI-64 Interface Identifier" },
+	{ "linklocal", "IPv6 Link-local Address derived from a MAC address" },
+	{ "fields", "MAC Address Flags" },
+}
+
+func macFields(mac [6]byte) string {
+	cast := "unicast"
+	if mac[0] & 0x01 != 0 { cast = "multicast" }
+	if mac == [6]byte{ 0xff, 0xff, 0xff, 0xff, 0xff, 0xff } { cast = "broadcast" }
+	administration := "universally administered"
+	if mac[0] & 0x02 != 0 { administration = "locally administered" }
+	fields := []idField{
+		{ "type", cast },
+		{ "administration", administration },
+	}
+	if mac[0] & 0x02 == 0 { fields = append(fields, idField{ "oui", strings.ToUpper(joinHexGroups(mac[0:3], 2, "-")) }) }
+	return formatIdFields(fields)
+}
+
+func addMacConversions(output *Conversions) {
+	for _, from := range macFormats {
+		for _, to := range macFormats {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"network", from.unit, to.unit, func(input string) (string, error) {
+					mac, err := from.parse(input)
+					if err != nil { return "", err }
+					return to.format(mac), nil
+				},
+			})
+		}
+	}
+
+	output.Add(Conversion{
+		"network", "mac", "eui64", func(input string) (string, error) {
+			mac, err := parseMac(input)
+			if err != nil { return "", err }
+			return formatEui64(macToEui64(mac)), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"network", "eui64", "mac", func(input string) (string, error) {
+			eui, err := parseEui64(input)
+			if err != nil { return "", err }
+			mac, err := eui64ToMac(eui)
+			if err != nil { return "", err }
+			return macFormats[0].format(mac), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"network", "mac", "linklocal", func(input string) (string, error) {
+			mac, err := parseMac(input)
+			if err != nil { return "", err }
+			var b [16]byte
+			b[0], b[1] = 0xfe, 0x80
+			eui := macToEui64(mac)
+			copy(b[8:], eui[:])
+			return netip.AddrFrom16(b).String(), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"network", "linklocal", "mac", func(input string) (string, error) {
+			addr, err := parseIp(input)
+			if err != nil { return "", err }
+			if !addr.Is6() || !addr.IsLinkLocalUnicast() { return "", errors.New("Not an IPv6 link-local address: \"" + input + "\"") }
+			b := addr.As16()
+			var eui [8]byte
+			copy(eui[:], b[8:])
+			mac, err := eui64ToMac(eui)
+			if err != nil { return "", err }
+			return macFormats[0].format(mac), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"network", "mac", "fields", func(input string) (string, error) {
+			mac, err := parseMac(input)
+			if err != nil { return "", err }
+			return macFields(mac), nil
+		},
+	})
+}
@@ -0,0 +1,167 @@
+package conversions
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var morseCodes = map[rune]string{
+	'A': ".-", 'B': "-...", 'C': "-.-.", 'D': "-..", 'E': ".", 'F': "..-.", 'G': "--.", 'H': "....",
+	'I': "..", 'J': ".---", 'K': "-.-", 'L': ".-..", 'M': "--", 'N': "-.", 'O': "---", 'P': ".--.",
+	'Q': "--.-", 'R': ".-.", 'S': "...", 'T': "-", 'U': "..-", 'V': "...-", 'W': ".--", 'X': "-..-",
+	'Y': "-.--", 'Z': "--..",
+	'0': "-----", '1': ".----", '2': "..---", '3': "...--", '4': "....-",
+	'5': ".....", '6': "-....", '7': "--...", '8': "---..", '9': "----.",
+	'.': ".-.-.-", ',': "--..--", '?': "..--..", '\'': ".----.", '!': "-.-.--", '/': "-..-.",
+	'(': "-.--.", ')': "-.--.-", '&': ".-...", ':': "---...", ';': "-.-.-.", '=': "-...-",
+	'+': ".-.-.", '-': "-....-", '_': "..--.-", '"': ".-..-.", '$': "...-..-", '@': ".--.-.",
+}
+
+// Prosigns whose code isn't also the code of a character, which are decoded as such
+var morseProsigns = map[string]string{
+	"...-.-": "SK",
+	"...---...": "SOS",
+	"........": "HH",
+	"-.-.-": "CT",
+	"...-.": "SN",
+}
+
+// Encodes text in Morse code. Prosigns are written between angle brackets, eg. "<SK>",
+// and are sent as their letters without gaps.
+func (this *Conversions) encodeMorse(input string) (string, error) {
+	letterSeparator := this.option("morse-letter", " ")
+	wordSeparator := this.option("morse-word", " / ")
+	var words []string
+	for _, word := range strings.Fields(strings.ToUpper(input)) {
+		var letters []string
+		for len(word) > 0 {
+			if end := strings.IndexByte(word, '>'); word[0] == '<' && end > 1 {
+				prosign := ""
+				for _, r := range word[1:end] {
+					code, ok := morseCodes[r]
+					if !ok { return "", errors.New("Invalid character in prosign: '" + string(r) + "'") }
+					prosign += code
+				}
+				letters = append(letters, prosign)
+				word = word[end + 1:]
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(word)
+			code, ok := morseCodes[r]
+			if !ok { return "", errors.New("No Morse code for character '" + string(r) + "'") }
+			letters = append(letters, code)
+			word = word[size:]
+		}
+		words = append(words, strings.Join(letters, letterSeparator))
+	}
+	return strings.Join(words, wordSeparator), nil
+}
+
+func (this *Conversions) decodeMorse(input string) (string, error) {
+	letterSeparator := this.option("morse-letter", " ")
+	// The spaces around a separator such as " / " are optional, unless it is only spaces,
+	// such as the three spaces of the standard gap between words
+	wordSeparator := this.option("morse-word", " / ")
+	if strings.TrimSpace(wordSeparator) != "" { wordSeparator = strings.TrimSpace(wordSeparator) }
+	decoded := make(map[string]string)
+	for r, code := range morseCodes {
+		decoded[code] = string(r)
+	}
+	for code, prosign := range morseProsigns {
+		decoded[code] = "<" + prosign + ">"
+	}
+
+	var words []string
+	for _, word := range strings.Split(input, wordSeparator) {
+		if strings.TrimSpace(word) == "" { continue }
+		var letters []string
+		if strings.TrimSpace(letterSeparator) == "" {
+			letters = strings.Fields(word)
+		} else {
+			letters = strings.Split(strings.TrimSpace(word), letterSeparator)
+		}
+		output := ""
+		for _, letter := range letters {
+			// Some keyboards and fonts turn dashes into other characters
+			letter = strings.NewReplacer("_", "-", "−", "-", "–", "-", "·", ".", "•", ".").Replace(strings.TrimSpace(letter))
+			if letter == "" { continue }
+			s, ok := decoded[letter]
+			if !ok { return "", errors.New("Unknown Morse code: \"" + letter + "\"") }
+			output += s
+		}
+		words = append(words, output)
+	}
+	return strings.Join(words, " "), nil
+}
+
+var natoWords = []string{
+	"Alfa", "Bravo", "Charlie", "Delta", "Echo", "Foxtrot", "Golf", "Hotel", "India", "Juliett",
+	"Kilo", "Lima", "Mike", "November", "Oscar", "Papa", "Quebec", "Romeo", "Sierra", "Tango",
+	"Uniform", "Victor", "Whiskey", "X-ray", "Yankee", "Zulu",
+}
+
+var natoDigits = []string{ "Zero", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine" }
+
+var natoSymbols = map[rune]string{
+	'-': "Dash", '.': "Dot", '/': "Slash", '_': "Underscore", ':': "Colon", '@': "At", '#': "Hash", '+': "Plus",
+}
+
+// Spells text with the NATO phonetic alphabet, one word per character. Spaces are
+// spelled as "Space" so that the number of characters can be followed.
+func encodeNato(input string) string {
+	var words []string
+	for _, r := range strings.TrimSpace(input) {
+		upper := unicode.ToUpper(r)
+		switch {
+			case upper >= 'A' && upper <= 'Z': words = append(words, natoWords[upper - 'A'])
+			case r >= '0' && r <= '9': words = append(words, natoDigits[r - '0'])
+			case unicode.IsSpace(r): words = append(words, "Space")
+			default:
+				if word, ok := natoSymbols[r]; ok {
+					words = append(words, word)
+				} else {
+					words = append(words, string(r))
+				}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func decodeNato(input string) (string, error) {
+	decoded := map[string]string{
+		// Alternative spellings, and the digits of ICAO radiotelephony
+		"alpha": "A", "juliet": "J", "xray": "X", "whisky": "W", "tree": "3", "fower": "4", "fife": "5", "niner": "9", "space": " ",
+	}
+	for i, word := range natoWords {
+		decoded[strings.ToLower(word)] = string(rune('A' + i))
+	}
+	for i, word := range natoDigits {
+		decoded[strings.ToLower(word)] = string(rune('0' + i))
+	}
+	for r, word := range natoSymbols {
+		decoded[strings.ToLower(word)] = string(r)
+	}
+	output := ""
+	for _, word := range strings.Fields(input) {
+		s, ok := decoded[strings.Replace(strings.ToLower(word), "-", "", -1)]
+		if !ok {
+			if len([]rune(word)) != 1 { return "", errors.New("Unknown phonetic alphabet word: \"" + word + "\"") }
+			s = word
+		}
+		output += s
+	}
+	return output, nil
+}
+
+func addMorseConversions(output *Conversions) {
+	output.Add(Conversion{ "text", "text", "morse", output.encodeMorse })
+	output.Add(Conversion{ "text", "morse", "text", output.decodeMorse })
+	output.Add(Conversion{
+		"text", "text", "nato", func(input string) (string, error) {
+			return encodeNato(input), nil
+		},
+	})
+	output.Add(Conversion{ "text", "nato", "text", decodeNato })
+}
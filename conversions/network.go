@@ -0,0 +1,279 @@
+package conversions
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+type networkUnit struct {
+	unit string
+	name string
+}
+
+var networkUnits = []networkUnit{
+	{ "ip", "IPv4 or IPv6 Address (IPv6 compressed)" },
+	{ "ipfull", "Expanded IPv6 Address" },
+	{ "dec", "IP Address as an Integer (byte order set with --byte-order)" },
+	{ "hex", "IP Address in Hexadecimal (byte order set with --byte-order)" },
+	{ "mapped", "IPv4-mapped IPv6 Address, eg. ::ffff:10.0.0.1" },
+	{ "cidr", "CIDR Block, eg. 10.0.0.0/8" },
+	{ "range", "Address Range, eg. 10.0.0.0-10.255.255.255" },
+	{ "netmask", "Netmask, eg. 255.255.255.0" },
+	{ "prefix", "IPv4 Prefix Length, eg. 24" },
+}
+
+func findNetworkUnit(unit string) (networkUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range networkUnits {
+		if u.unit == unit { return u, true }
+	}
+	return networkUnit{}, false
+}
+
+func parseIp(input string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(input))
+	if err != nil { return addr, errors.New("Invalid IP address: \"" + input + "\"") }
+	return addr, nil
+}
+
+// Whether integers are read and written in network ("big") or little-endian byte order
+func (this *Conversions) littleEndian() (bool, error) {
+	order := strings.ToLower(this.option("byte-order", "big"))
+	switch order {
+		case "big", "network": return false, nil
+		case "little", "host": return true, nil
+	}
+	return false, errors.New("Invalid byte order, expected \"big\" or \"little\": \"" + order + "\"")
+}
+
+func reverseBytes(b []byte) []byte {
+	output := make([]byte, len(b))
+	for i := range b {
+		output[len(b) - 1 - i] = b[i]
+	}
+	return output
+}
+
+// Returns the bytes of an address in the configured byte order
+func (this *Conversions) ipBytes(addr netip.Addr) ([]byte, error) {
+	little, err := this.littleEndian()
+	if err != nil { return nil, err }
+	b := addr.AsSlice()
+	if little { b = reverseBytes(b) }
+	return b, nil
+}
+
+// Builds an address from 4 or 16 bytes in the configured byte order
+func (this *Conversions) ipFromBytes(b []byte) (netip.Addr, error) {
+	little, err := this.littleEndian()
+	if err != nil { return netip.Addr{}, err }
+	if little { b = reverseBytes(b) }
+	addr, ok := netip.AddrFromSlice(b)
+	if !ok { return addr, errors.New("Invalid address length") }
+	return addr, nil
+}
+
+func (this *Conversions) ipFromInt(input string) (netip.Addr, error) {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(input), 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 128 { return netip.Addr{}, errors.New("Invalid address, expected an integer between 0 and 2^128-1: \"" + input + "\"") }
+	size := 4
+	if n.BitLen() > 32 { size = 16 }
+	return this.ipFromBytes(n.FillBytes(make([]byte, size)))
+}
+
+func (this *Conversions) ipFromHex(input string) (netip.Addr, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	s = strings.TrimPrefix(s, "0x")
+	s = strings.Replace(s, ":", "", -1)
+	if len(s) % 2 == 1 { s = "0" + s }
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) > 16 { return netip.Addr{}, errors.New("Invalid hexadecimal address: \"" + input + "\"") }
+	size := 4
+	if len(b) > 4 { size = 16 }
+	return this.ipFromBytes(append(make([]byte, size - len(b)), b...))
+}
+
+func formatIpFull(addr netip.Addr) string {
+	if addr.Is4() { return addr.String() }
+	s := addr.StringExpanded()
+	if addr.Zone() != "" { s = strings.TrimSuffix(s, "%" + addr.Zone()) + "%" + addr.Zone() }
+	return s
+}
+
+// Returns the last address of a prefix by setting all its host bits
+func lastIp(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b) * 8; i++ {
+		b[i / 8] |= 0x80 >> uint(i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// Parses a CIDR block. A single address is a block of one address.
+func parseCidr(input string) (netip.Prefix, error) {
+	s := strings.TrimSpace(input)
+	if !strings.Contains(s, "/") {
+		addr, err := parseIp(s)
+		if err != nil { return netip.Prefix{}, err }
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil { return prefix, errors.New("Invalid CIDR block, expected eg. 10.0.0.0/8: \"" + input + "\"") }
+	return prefix.Masked(), nil
+}
+
+func parseIpRange(input string) (netip.Addr, netip.Addr, error) {
+	parts := strings.Split(input, "-")
+	if len(parts) != 2 { return netip.Addr{}, netip.Addr{}, errors.New("Invalid range, expected eg. 10.0.0.0-10.0.0.255: \"" + input + "\"") }
+	first, err := parseIp(parts[0])
+	if err != nil { return first, first, err }
+	last, err := parseIp(parts[1])
+	if err != nil { return first, last, err }
+	if first.Is4() != last.Is4() { return first, last, errors.New("Both ends of a range must be IPv4 or IPv6 addresses") }
+	if last.Less(first) { return first, last, errors.New("The end of the range is before its start") }
+	return first, last, nil
+}
+
+// Splits a range into the smallest list of CIDR blocks that covers it exactly
+func rangeToCidrs(first netip.Addr, last netip.Addr) []netip.Prefix {
+	bits := first.BitLen()
+	start := new(big.Int).SetBytes(first.AsSlice())
+	end := new(big.Int).SetBytes(last.AsSlice())
+	one := big.NewInt(1)
+	var output []netip.Prefix
+	for start.Cmp(end) <= 0 {
+		// The largest block is limited by the alignment of the start and by the remaining size
+		size := 0
+		for size < bits && start.Bit(size) == 0 {
+			blockEnd := new(big.Int).Lsh(one, uint(size + 1))
+			blockEnd.Add(blockEnd, start).Sub(blockEnd, one)
+			if blockEnd.Cmp(end) > 0 { break }
+			size++
+		}
+		addr, _ := netip.AddrFromSlice(start.FillBytes(make([]byte, bits / 8)))
+		output = append(output, netip.PrefixFrom(addr, bits - size))
+		start.Add(start, new(big.Int).Lsh(one, uint(size)))
+	}
+	return output
+}
+
+func netmaskFromBits(ones int, bits int) netip.Addr {
+	b := make([]byte, bits / 8)
+	for i := 0; i < ones; i++ {
+		b[i / 8] |= 0x80 >> uint(i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+func netmaskToPrefixLength(input string) (int, error) {
+	mask, err := parseIp(input)
+	if err != nil { return 0, err }
+	b := mask.AsSlice()
+	ones := 0
+	for ones < len(b) * 8 && b[ones / 8] & (0x80 >> uint(ones % 8)) != 0 {
+		ones++
+	}
+	if netmaskFromBits(ones, len(b) * 8) != mask { return 0, errors.New("Invalid netmask, the bits set must be contiguous: \"" + input + "\"") }
+	return ones, nil
+}
+
+func addNetworkConversions(output *Conversions) {
+	add := func(from string, to string, convert func(input string) (string, error)) {
+		output.Add(Conversion{ "network", from, to, convert })
+	}
+
+	add("ip", "dec", func(input string) (string, error) {
+		addr, err := parseIp(input)
+		if err != nil { return "", err }
+		b, err := output.ipBytes(addr)
+		if err != nil { return "", err }
+		return new(big.Int).SetBytes(b).String(), nil
+	})
+
+	add("dec", "ip", func(input string) (string, error) {
+		addr, err := output.ipFromInt(input)
+		if err != nil { return "", err }
+		return addr.String(), nil
+	})
+
+	add("ip", "hex", func(input string) (string, error) {
+		addr, err := parseIp(input)
+		if err != nil { return "", err }
+		b, err := output.ipBytes(addr)
+		if err != nil { return "", err }
+		return hex.EncodeToString(b), nil
+	})
+
+	add("hex", "ip", func(input string) (string, error) {
+		addr, err := output.ipFromHex(input)
+		if err != nil { return "", err }
+		return addr.String(), nil
+	})
+
+	add("ip", "ipfull", func(input string) (string, error) {
+		addr, err := parseIp(input)
+		if err != nil { return "", err }
+		return formatIpFull(addr), nil
+	})
+
+	add("ipfull", "ip", func(input string) (string, error) {
+		addr, err := parseIp(input)
+		if err != nil { return "", err }
+		return addr.String(), nil
+	})
+
+	add("ip", "mapped", func(input string) (string, error) {
+		addr, err := parseIp(input)
+		if err != nil { return "", err }
+		if !addr.Is4() && !addr.Is4In6() { return "", errors.New("Only IPv4 addresses can be mapped to IPv6: \"" + input + "\"") }
+		return "::ffff:" + addr.Unmap().String(), nil
+	})
+
+	add("mapped", "ip", func(input string) (string, error) {
+		addr, err := parseIp(input)
+		if err != nil { return "", err }
+		if !addr.Is4In6() { return "", errors.New("Not an IPv4-mapped IPv6 address: \"" + input + "\"") }
+		return addr.Unmap().String(), nil
+	})
+
+	add("cidr", "range", func(input string) (string, error) {
+		prefix, err := parseCidr(input)
+		if err != nil { return "", err }
+		return prefix.Addr().String() + "-" + lastIp(prefix).String(), nil
+	})
+
+	add("range", "cidr", func(input string) (string, error) {
+		first, last, err := parseIpRange(input)
+		if err != nil { return "", err }
+		var lines []string
+		for _, prefix := range rangeToCidrs(first, last) {
+			lines = append(lines, prefix.String())
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+
+	add("cidr", "netmask", func(input string) (string, error) {
+		prefix, err := parseCidr(input)
+		if err != nil { return "", err }
+		return netmaskFromBits(prefix.Bits(), prefix.Addr().BitLen()).String(), nil
+	})
+
+	add("netmask", "prefix", func(input string) (string, error) {
+		ones, err := netmaskToPrefixLength(input)
+		if err != nil { return "", err }
+		return strconv.Itoa(ones), nil
+	})
+
+	add("prefix", "netmask", func(input string) (string, error) {
+		s := strings.TrimPrefix(strings.TrimSpace(input), "/")
+		ones, err := strconv.Atoi(s)
+		if err != nil || ones < 0 || ones > 32 { return "", errors.New("Invalid IPv4 prefix length, expected a number between 0 and 32: \"" + input + "\"") }
+		return netmaskFromBits(ones, 32).String(), nil
+	})
+}
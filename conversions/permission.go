@@ -0,0 +1,233 @@
+package conversions
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	permSetuid = 04000
+	permSetgid = 02000
+	permSticky = 01000
+)
+
+type permissionUnit struct {
+	unit string
+	name string
+}
+
+var permissionUnits = []permissionUnit{
+	{ "oct", "Octal Mode, eg. 4755" },
+	{ "perm", "Symbolic Mode, eg. rwsr-xr-x" },
+	{ "chmod", "Symbolic chmod Expression, eg. u+x,go-w (applied to --mode)" },
+	{ "umask", "Umask, converted to the resulting file and directory modes" },
+}
+
+func findPermissionUnit(unit string) (permissionUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range permissionUnits {
+		if u.unit == unit { return u, true }
+	}
+	return permissionUnit{}, false
+}
+
+func parseOctalMode(input string) (uint32, error) {
+	s := strings.TrimSpace(input)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 07777 { return 0, errors.New("Invalid octal mode, expected up to four octal digits: \"" + input + "\"") }
+	return uint32(n), nil
+}
+
+func formatOctalMode(mode uint32) string {
+	return leftPad(strconv.FormatUint(uint64(mode), 8), 4, '0')
+}
+
+// The characters of a symbolic mode, with the special bit that replaces the
+// execute character of each class.
+var permissionClasses = []struct {
+	shift uint
+	special uint32
+	specialChar byte
+}{
+	{ 6, permSetuid, 's' },
+	{ 3, permSetgid, 's' },
+	{ 0, permSticky, 't' },
+}
+
+func formatSymbolicMode(mode uint32) string {
+	output := ""
+	for _, class := range permissionClasses {
+		bits := mode >> class.shift & 7
+		for _, c := range []struct{ bit uint32; char string }{ { 4, "r" }, { 2, "w" } } {
+			if bits & c.bit != 0 {
+				output += c.char
+			} else {
+				output += "-"
+			}
+		}
+		execute := bits & 1 != 0
+		special := mode & class.special != 0
+		switch {
+			case special && execute: output += string(class.specialChar)
+			case special: output += strings.ToUpper(string(class.specialChar))
+			case execute: output += "x"
+			default: output += "-"
+		}
+	}
+	return output
+}
+
+// Parses a symbolic mode such as "rwsr-xr-x", optionally preceded by a file type
+// character as displayed by ls, eg. "drwxr-xr-x".
+func parseSymbolicMode(input string) (uint32, error) {
+	s := strings.TrimSpace(input)
+	if len(s) == 10 { s = s[1:] }
+	invalidError := errors.New("Invalid symbolic mode, expected eg. rwxr-xr-x: \"" + input + "\"")
+	if len(s) != 9 { return 0, invalidError }
+	var mode uint32
+	for i, class := range permissionClasses {
+		chunk := s[i * 3:i * 3 + 3]
+		if chunk[0] == 'r' {
+			mode |= 4 << class.shift
+		} else if chunk[0] != '-' {
+			return 0, invalidError
+		}
+		if chunk[1] == 'w' {
+			mode |= 2 << class.shift
+		} else if chunk[1] != '-' {
+			return 0, invalidError
+		}
+		switch chunk[2] {
+			case 'x': mode |= 1 << class.shift
+			case class.specialChar: mode |= 1 << class.shift | class.special
+			case class.specialChar - 'a' + 'A': mode |= class.special
+			case '-':
+			default: return 0, invalidError
+		}
+	}
+	return mode, nil
+}
+
+// Applies a chmod symbolic expression, such as "u+x,go-w" or "a=rX", to a mode. An
+// octal expression replaces the mode. As the type of the file isn't known, "X" adds
+// the execute bit only if it is already set for one of the classes.
+func applyChmod(mode uint32, expression string) (uint32, error) {
+	s := strings.TrimSpace(expression)
+	if octal, err := parseOctalMode(s); err == nil { return octal, nil }
+
+	for _, clause := range strings.Split(s, ",") {
+		invalidError := errors.New("Invalid chmod expression: \"" + clause + "\"")
+		i := 0
+		var who uint32
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			switch clause[i] {
+				case 'u': who |= 04700
+				case 'g': who |= 02070
+				case 'o': who |= 01007
+				case 'a': who |= 07777
+			}
+		}
+		if who == 0 { who = 07777 }
+		if i >= len(clause) { return 0, invalidError }
+
+		for i < len(clause) {
+			op := clause[i]
+			if op != '+' && op != '-' && op != '=' { return 0, invalidError }
+			i++
+			var bits uint32
+			for ; i < len(clause) && strings.IndexByte("+-=", clause[i]) < 0; i++ {
+				switch clause[i] {
+					case 'r': bits |= 0444
+					case 'w': bits |= 0222
+					case 'x': bits |= 0111
+					case 'X': if mode & 0111 != 0 { bits |= 0111 }
+					case 's': bits |= permSetuid | permSetgid
+					case 't': bits |= permSticky
+					// Copies the permissions of another class to every class
+					case 'u': bits |= (mode >> 6 & 7) * 0111
+					case 'g': bits |= (mode >> 3 & 7) * 0111
+					case 'o': bits |= (mode & 7) * 0111
+					default: return 0, invalidError
+				}
+			}
+			bits &= who
+			switch op {
+				case '+': mode |= bits
+				case '-': mode &^= bits
+				case '=': mode = mode &^ who | bits
+			}
+		}
+	}
+	return mode, nil
+}
+
+// Returns the modes of new files and directories created with the given umask
+func umaskModes(input string) (uint32, uint32, error) {
+	umask, err := parseOctalMode(input)
+	if err != nil { return 0, 0, err }
+	if umask > 0777 { return 0, 0, errors.New("Invalid umask, expected at most 0777: \"" + input + "\"") }
+	return 0666 &^ umask, 0777 &^ umask, nil
+}
+
+func addPermissionConversions(output *Conversions) {
+	chmodConv := func(input string) (uint32, error) {
+		mode, err := parseOctalMode(output.option("mode", "0644"))
+		if err != nil { return 0, err }
+		return applyChmod(mode, input)
+	}
+
+	umaskConv := func(input string, format func(mode uint32) string) (string, error) {
+		fileMode, directoryMode, err := umaskModes(input)
+		if err != nil { return "", err }
+		return formatIdFields([]idField{
+			{ "files", format(fileMode) },
+			{ "directories", format(directoryMode) },
+		}), nil
+	}
+
+	output.Add(Conversion{
+		"permission", "oct", "perm", func(input string) (string, error) {
+			mode, err := parseOctalMode(input)
+			if err != nil { return "", err }
+			return formatSymbolicMode(mode), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"permission", "perm", "oct", func(input string) (string, error) {
+			mode, err := parseSymbolicMode(input)
+			if err != nil { return "", err }
+			return formatOctalMode(mode), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"permission", "chmod", "oct", func(input string) (string, error) {
+			mode, err := chmodConv(input)
+			if err != nil { return "", err }
+			return formatOctalMode(mode), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"permission", "chmod", "perm", func(input string) (string, error) {
+			mode, err := chmodConv(input)
+			if err != nil { return "", err }
+			return formatSymbolicMode(mode), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"permission", "umask", "oct", func(input string) (string, error) {
+			return umaskConv(input, formatOctalMode)
+		},
+	})
+
+	output.Add(Conversion{
+		"permission", "umask", "perm", func(input string) (string, error) {
+			return umaskConv(input, formatSymbolicMode)
+		},
+	})
+}
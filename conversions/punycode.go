@@ -0,0 +1,252 @@
+package conversions
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Punycode parameters (RFC 3492)
+const (
+	punycodeBase = 36
+	punycodeTMin = 1
+	punycodeTMax = 26
+	punycodeSkew = 38
+	punycodeDamp = 700
+	punycodeInitialBias = 72
+	punycodeInitialN = 128
+)
+
+func punycodeAdapt(delta int, numPoints int, firstTime bool) int {
+	if firstTime {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase - punycodeTMin) * punycodeTMax) / 2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase - punycodeTMin + 1) * delta / (delta + punycodeSkew)
+}
+
+func punycodeThreshold(k int, bias int) int {
+	switch {
+		case k <= bias: return punycodeTMin
+		case k >= bias + punycodeTMax: return punycodeTMax
+	}
+	return k - bias
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 { return byte('a' + d) }
+	return byte('0' + d - 26)
+}
+
+func punycodeEncode(input string) (string, error) {
+	runes := []rune(input)
+	var output []byte
+	for _, r := range runes {
+		if r < 0x80 { output = append(output, byte(r)) }
+	}
+	basicCount := len(output)
+	handled := basicCount
+	if basicCount > 0 { output = append(output, '-') }
+
+	n := punycodeInitialN
+	delta := 0
+	bias := punycodeInitialBias
+	for handled < len(runes) {
+		// The smallest code point that hasn't been handled yet
+		m := int(unicode.MaxRune) + 1
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m { m = int(r) }
+		}
+		if (m - n) > (1 << 30) / (handled + 1) { return "", errors.New("Punycode overflow") }
+		delta += (m - n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n { delta++ }
+			if int(r) == n {
+				q := delta
+				for k := punycodeBase; ; k += punycodeBase {
+					t := punycodeThreshold(k, bias)
+					if q < t { break }
+					output = append(output, punycodeDigit(t + (q - t) % (punycodeBase - t)))
+					q = (q - t) / (punycodeBase - t)
+				}
+				output = append(output, punycodeDigit(q))
+				bias = punycodeAdapt(delta, handled + 1, handled == basicCount)
+				delta = 0
+				handled++
+			}
+		}
+		delta++
+		n++
+	}
+	return string(output), nil
+}
+
+func punycodeDecode(input string) (string, error) {
+	var output []rune
+	rest := input
+	if pos := strings.LastIndexByte(input, '-'); pos >= 0 {
+		for i := 0; i < pos; i++ {
+			if input[i] >= 0x80 { return "", errors.New("Invalid punycode, non-ASCII character: \"" + input + "\"") }
+			output = append(output, rune(input[i]))
+		}
+		rest = input[pos + 1:]
+	}
+
+	n := punycodeInitialN
+	i := 0
+	bias := punycodeInitialBias
+	for pos := 0; pos < len(rest); {
+		oldI := i
+		w := 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if pos >= len(rest) { return "", errors.New("Invalid punycode, truncated input: \"" + input + "\"") }
+			c := rest[pos]
+			pos++
+			var digit int
+			switch {
+				case c >= 'a' && c <= 'z': digit = int(c - 'a')
+				case c >= 'A' && c <= 'Z': digit = int(c - 'A')
+				case c >= '0' && c <= '9': digit = int(c - '0') + 26
+				default: return "", errors.New("Invalid punycode character '" + string(c) + "': \"" + input + "\"")
+			}
+			if digit > ((1 << 30) - i) / w { return "", errors.New("Punycode overflow") }
+			i += digit * w
+			t := punycodeThreshold(k, bias)
+			if digit < t { break }
+			w *= punycodeBase - t
+		}
+		bias = punycodeAdapt(i - oldI, len(output) + 1, oldI == 0)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > unicode.MaxRune || !utf8.ValidRune(rune(n)) { return "", errors.New("Invalid punycode, bad code point: \"" + input + "\"") }
+		output = append(output[:i], append([]rune{ rune(n) }, output[i:]...)...)
+		i++
+	}
+	return string(output), nil
+}
+
+// Validates a label with the hyphen and length rules of IDNA 2008
+func validateDomainLabel(label string, ascii string) error {
+	if label == "" { return errors.New("Empty label") }
+	if len(ascii) > 63 { return errors.New("Label is longer than 63 characters: \"" + ascii + "\"") }
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") { return errors.New("Label starts or ends with a hyphen: \"" + label + "\"") }
+	if len(label) >= 4 && label[2:4] == "--" { return errors.New("Label has hyphens in the third and fourth positions: \"" + label + "\"") }
+	for _, r := range label {
+		if r < 0x80 && !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return errors.New("Invalid character '" + string(r) + "' in label \"" + label + "\"")
+		}
+	}
+	if unicode.Is(unicode.M, []rune(label)[0]) { return errors.New("Label starts with a combining mark: \"" + label + "\"") }
+	return validateIdnaLabel(label)
+}
+
+func isAsciiString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 { return false }
+	}
+	return true
+}
+
+// Processes a domain name with the nontransitional processing of UTS #46 and returns the
+// Unicode and ASCII forms of its labels. The domain name is mapped and normalised to NFC,
+// and labels that are already encoded must be in canonical punycode form and valid
+// without any mapping.
+func processDomain(domain string) ([]string, []string, error) {
+	domain, err := mapDomain(strings.TrimSpace(domain))
+	if err != nil { return nil, nil, err }
+	var unicodeLabels, asciiLabels []string
+	for _, label := range strings.Split(domain, ".") {
+		decoded := label
+		if strings.HasPrefix(label, "xn--") {
+			decoded, err = punycodeDecode(label[4:])
+			if err != nil { return nil, nil, err }
+			encoded, err := punycodeEncode(decoded)
+			if err != nil || "xn--" + encoded != label || isAsciiString(decoded) { return nil, nil, errors.New("Label is not in canonical punycode form: \"" + label + "\"") }
+			if mapped, err := mapDomain(decoded); err != nil || mapped != decoded {
+				return nil, nil, errors.New("Label has characters that aren't valid without mapping: \"" + label + "\"")
+			}
+		}
+		ascii := decoded
+		if !isAsciiString(decoded) {
+			encoded, err := punycodeEncode(decoded)
+			if err != nil { return nil, nil, err }
+			ascii = "xn--" + encoded
+		}
+		if err := validateDomainLabel(decoded, ascii); err != nil { return nil, nil, err }
+		unicodeLabels = append(unicodeLabels, decoded)
+		asciiLabels = append(asciiLabels, ascii)
+	}
+	return unicodeLabels, asciiLabels, nil
+}
+
+// A domain name that ends with a full stop is rooted, and keeps it
+func splitRootedDomain(domain string) (string, string) {
+	domain = strings.TrimSpace(domain)
+	for _, stop := range []string{ ".", "。", "．", "｡" } {
+		if strings.HasSuffix(domain, stop) { return strings.TrimSuffix(domain, stop), "." }
+	}
+	return domain, ""
+}
+
+func domainToAscii(domain string) (string, error) {
+	domain, root := splitRootedDomain(domain)
+	_, labels, err := processDomain(domain)
+	if err != nil { return "", err }
+	output := strings.Join(labels, ".")
+	if len(output) > 253 { return "", errors.New("Domain name is longer than 253 characters") }
+	return output + root, nil
+}
+
+func domainToUnicode(domain string) (string, error) {
+	domain, root := splitRootedDomain(domain)
+	labels, _, err := processDomain(domain)
+	if err != nil { return "", err }
+	return strings.Join(labels, ".") + root, nil
+}
+
+// Applies a domain conversion to the host of a URL, leaving the rest of it as it
+// is. Input without a scheme is converted as a domain name.
+func convertUrlHost(input string, convert func(domain string) (string, error)) (string, error) {
+	s := strings.TrimSpace(input)
+	schemeEnd := strings.Index(s, "://")
+	if schemeEnd < 0 { return convert(s) }
+	authorityStart := schemeEnd + 3
+	authorityEnd := len(s)
+	if end := strings.IndexAny(s[authorityStart:], "/?#"); end >= 0 { authorityEnd = authorityStart + end }
+	authority := s[authorityStart:authorityEnd]
+	hostStart := strings.LastIndex(authority, "@") + 1
+	host := authority[hostStart:]
+	// IPv6 addresses in brackets are left as they are
+	if strings.HasPrefix(host, "[") { return s, nil }
+	port := ""
+	if colon := strings.LastIndex(host, ":"); colon >= 0 {
+		port = host[colon:]
+		host = host[0:colon]
+	}
+	converted, err := convert(host)
+	if err != nil { return "", err }
+	return s[0:authorityStart] + authority[0:hostStart] + converted + port + s[authorityEnd:], nil
+}
+
+func addPunycodeConversions(output *Conversions) {
+	output.Add(Conversion{
+		"domain", "unicode", "punycode", func(input string) (string, error) {
+			return convertUrlHost(input, domainToAscii)
+		},
+	})
+
+	output.Add(Conversion{
+		"domain", "punycode", "unicode", func(input string) (string, error) {
+			return convertUrlHost(input, domainToUnicode)
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package conversions
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// An absolute temperature t in a given scale is (t + offset) * factor Kelvins. A
+// temperature difference only uses the factor.
+type temperatureScale struct {
+	unit string
+	name string
+	symbol string
+	factor float64
+	offset float64
+}
+
+var temperatureScales = []temperatureScale{
+	{ "c", "Celsius", "°C", 1, 273.15 },
+	{ "f", "Fahrenheit", "°F", 5.0 / 9.0, 459.67 },
+	{ "k", "Kelvin", "K", 1, 0 },
+	{ "r", "Rankine", "°R", 5.0 / 9.0, 0 },
+	{ "re", "Réaumur", "°Ré", 1.25, 218.52 },
+}
+
+func findTemperatureScale(unit string) (temperatureScale, bool) {
+	unit = strings.ToLower(unit)
+	for _, t := range temperatureScales {
+		if t.unit == unit { return t, true }
+	}
+	return temperatureScale{}, false
+}
+
+func addTemperatureConversions(output *Conversions) {
+	temperatureConv := func(input string, from temperatureScale, to temperatureScale) (string, error) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil { return "", err }
+		if output.option("delta", "false") == "true" {
+			return formatFloat(value * from.factor / to.factor), nil
+		}
+		kelvins := (value + from.offset) * from.factor
+		if kelvins < 0 { return "", errors.New("Temperature is below absolute zero: " + input + " " + from.symbol) }
+		return formatFloat(kelvins / to.factor - to.offset), nil
+	}
+
+	for _, from := range temperatureScales {
+		for _, to := range temperatureScales {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"temperature", from.unit, to.unit, func(input string) (string, error) {
+					return temperatureConv(input, from, to)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package conversions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Characters that encodeURIComponent leaves as they are
+const unreservedUrlCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.!~*'()"
+
+func percentEncode(input string) string {
+	var output strings.Builder
+	for i := 0; i < len(input); i++ {
+		if strings.IndexByte(unreservedUrlCharacters, input[i]) >= 0 {
+			output.WriteByte(input[i])
+		} else {
+			fmt.Fprintf(&output, "%%%02X", input[i])
+		}
+	}
+	return output.String()
+}
+
+func escapeXml(input string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "'", "&apos;")
+	return replacer.Replace(input)
+}
+
+// Decodes the five predefined XML entities and numeric character references
+func unescapeXml(input string) (string, error) {
+	var output strings.Builder
+	for i := 0; i < len(input); i++ {
+		if input[i] != '&' {
+			output.WriteByte(input[i])
+			continue
+		}
+		end := strings.IndexByte(input[i:], ';')
+		if end < 0 { return "", errors.New("Unterminated entity at position " + strconv.Itoa(i)) }
+		entity := input[i + 1:i + end]
+		switch entity {
+			case "amp": output.WriteByte('&')
+			case "lt": output.WriteByte('<')
+			case "gt": output.WriteByte('>')
+			case "quot": output.WriteByte('"')
+			case "apos": output.WriteByte('\'')
+			default:
+				if !strings.HasPrefix(entity, "#") { return "", errors.New("Unknown XML entity: \"&" + entity + ";\"") }
+				var n uint64
+				var err error
+				if strings.HasPrefix(entity, "#x") || strings.HasPrefix(entity, "#X") {
+					n, err = strconv.ParseUint(entity[2:], 16, 32)
+				} else {
+					n, err = strconv.ParseUint(entity[1:], 10, 32)
+				}
+				if err != nil || !utf8.ValidRune(rune(n)) { return "", errors.New("Invalid character reference: \"&" + entity + ";\"") }
+				output.WriteRune(rune(n))
+		}
+		i += end
+	}
+	return output.String(), nil
+}
+
+func encodeJsonString(input string) (string, error) {
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(input)
+	if err != nil { return "", err }
+	return strings.TrimSuffix(output.String(), "\n"), nil
+}
+
+// Decodes a JSON string, with or without its surrounding quotes
+func decodeJsonString(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if len(s) < 2 || s[0] != '"' || s[len(s) - 1] != '"' { s = "\"" + s + "\"" }
+	var output string
+	err := json.Unmarshal([]byte(s), &output)
+	if err != nil { return "", errors.New("Invalid JSON string: " + err.Error()) }
+	return output, nil
+}
+
+// Decodes a Go string literal, with or without its surrounding quotes
+func decodeGoString(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if len(s) < 2 || (s[0] != '"' && s[0] != '`') || s[len(s) - 1] != s[0] { s = "\"" + s + "\"" }
+	output, err := strconv.Unquote(s)
+	if err != nil { return "", errors.New("Invalid Go string literal: \"" + input + "\"") }
+	return output, nil
+}
+
+var cEscapes = map[byte]string{
+	'\a': "\\a", '\b': "\\b", '\f': "\\f", '\n': "\\n", '\r': "\\r", '\t': "\\t", '\v': "\\v",
+	'\\': "\\\\", '"': "\\\"",
+}
+
+// Escapes a C string literal. Bytes that are not printable ASCII are written as
+// octal escapes, which unlike hexadecimal escapes can't run into the next character.
+func encodeCString(input string) string {
+	var output strings.Builder
+	output.WriteByte('"')
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if escape, ok := cEscapes[c]; ok {
+			output.WriteString(escape)
+		} else if c < 0x20 || c >= 0x7f {
+			fmt.Fprintf(&output, "\\%03o", c)
+		} else {
+			output.WriteByte(c)
+		}
+	}
+	output.WriteByte('"')
+	return output.String()
+}
+
+func decodeCString(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if len(s) >= 2 && s[0] == '"' && s[len(s) - 1] == '"' { s = s[1:len(s) - 1] }
+	var output strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			output.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) { return "", errors.New("Unterminated escape sequence at the end of the string") }
+		found := false
+		for c, escape := range cEscapes {
+			if escape[1] == s[i] {
+				output.WriteByte(c)
+				found = true
+			}
+		}
+		if found { continue }
+		switch {
+			case s[i] == '\'' || s[i] == '?':
+				output.WriteByte(s[i])
+			case s[i] >= '0' && s[i] <= '7':
+				end := i
+				for end < len(s) && end < i + 3 && s[end] >= '0' && s[end] <= '7' {
+					end++
+				}
+				n, _ := strconv.ParseUint(s[i:end], 8, 16)
+				if n > 0xff { return "", errors.New("Octal escape out of range: \"\\" + s[i:end] + "\"") }
+				output.WriteByte(byte(n))
+				i = end - 1
+			case s[i] == 'x' || s[i] == 'u' || s[i] == 'U':
+				digits := map[byte]int{ 'x': 2, 'u': 4, 'U': 8 }[s[i]]
+				if s[i] == 'x' {
+					// Hexadecimal escapes take as many digits as follow, but must fit in a byte
+					digits = 0
+					for i + 1 + digits < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[i + 1 + digits]) >= 0 {
+						digits++
+					}
+				}
+				if digits == 0 || i + digits >= len(s) { return "", errors.New("Invalid escape sequence: \"\\" + s[i:] + "\"") }
+				n, err := strconv.ParseUint(s[i + 1:i + 1 + digits], 16, 32)
+				if err != nil { return "", errors.New("Invalid escape sequence: \"\\" + s[i:i + 1 + digits] + "\"") }
+				if s[i] == 'x' {
+					if n > 0xff { return "", errors.New("Hexadecimal escape out of range: \"\\" + s[i:i + 1 + digits] + "\"") }
+					output.WriteByte(byte(n))
+				} else {
+					if !utf8.ValidRune(rune(n)) { return "", errors.New("Invalid universal character name: \"\\" + s[i:i + 1 + digits] + "\"") }
+					output.WriteRune(rune(n))
+				}
+				i += digits
+			default:
+				return "", errors.New("Unknown escape sequence: \"\\" + string(s[i]) + "\"")
+		}
+	}
+	return output.String(), nil
+}
+
+// Quotes a string for POSIX shells. Strings made only of safe characters are left
+// as they are, others are single-quoted.
+func shellQuote(input string) string {
+	if input == "" { return "''" }
+	safe := true
+	for _, c := range input {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("@%+=:,./-_", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe { return input }
+	return "'" + strings.Replace(input, "'", "'\\''", -1) + "'"
+}
+
+// Removes the quoting of a single POSIX shell word, as the shell would do, without
+// any expansion.
+func shellUnquote(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	var output strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+			case '\'':
+				end := strings.IndexByte(s[i + 1:], '\'')
+				if end < 0 { return "", errors.New("Unterminated single quote") }
+				output.WriteString(s[i + 1:i + 1 + end])
+				i += end + 1
+			case '"':
+				i++
+				for ; i < len(s) && s[i] != '"'; i++ {
+					// In double quotes, a backslash only escapes these characters
+					if s[i] == '\\' && i + 1 < len(s) && strings.IndexByte("$`\"\\\n", s[i + 1]) >= 0 { i++ }
+					output.WriteByte(s[i])
+				}
+				if i >= len(s) { return "", errors.New("Unterminated double quote") }
+			case '\\':
+				if i + 1 >= len(s) { return "", errors.New("Unterminated escape sequence at the end of the string") }
+				i++
+				output.WriteByte(s[i])
+			case ' ', '\t', '\n':
+				return "", errors.New("Unquoted whitespace, the input must be a single shell word")
+			default:
+				output.WriteByte(s[i])
+		}
+	}
+	return output.String(), nil
+}
+
+func encodeQuotedPrintable(input string) (string, error) {
+	var output bytes.Buffer
+	writer := quotedprintable.NewWriter(&output)
+	_, err := writer.Write([]byte(input))
+	if err != nil { return "", err }
+	err = writer.Close()
+	if err != nil { return "", err }
+	return output.String(), nil
+}
+
+func decodeQuotedPrintable(input string) (string, error) {
+	output, err := ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(input)))
+	if err != nil { return "", errors.New("Invalid quoted-printable text: " + err.Error()) }
+	return string(output), nil
+}
+
+type textEncoding struct {
+	unit string
+	name string
+	encode func(input string) (string, error)
+	decode func(input string) (string, error)
+}
+
+var textEncodings = []textEncoding{
+	{
+		"url", "Percent-encoded URL Component",
+		func(input string) (string, error) { return percentEncode(input), nil },
+		func(input string) (string, error) {
+			output, err := url.PathUnescape(input)
+			if err != nil { return "", errors.New("Invalid percent-encoding: " + err.Error()) }
+			return output, nil
+		},
+	},
+	{
+		"form", "Form-encoded Value (application/x-www-form-urlencoded)",
+		func(input string) (string, error) { return url.QueryEscape(input), nil },
+		func(input string) (string, error) {
+			output, err := url.QueryUnescape(input)
+			if err != nil { return "", errors.New("Invalid form encoding: " + err.Error()) }
+			return output, nil
+		},
+	},
+	{
+		"html", "HTML Entities",
+		func(input string) (string, error) { return html.EscapeString(input), nil },
+		func(input string) (string, error) { return html.UnescapeString(input), nil },
+	},
+	{ "xml", "XML Entities", func(input string) (string, error) { return escapeXml(input), nil }, unescapeXml },
+	{ "json", "JSON String", encodeJsonString, decodeJsonString },
+	{ "gostring", "Go String Literal", func(input string) (string, error) { return strconv.Quote(input), nil }, decodeGoString },
+	{ "cstring", "C String Literal", func(input string) (string, error) { return encodeCString(input), nil }, decodeCString },
+	{ "shell", "POSIX Shell Word", func(input string) (string, error) { return shellQuote(input), nil }, shellUnquote },
+	{ "qp", "MIME Quoted-printable", encodeQuotedPrintable, decodeQuotedPrintable },
+}
+
+func findTextEncoding(unit string) (textEncoding, bool) {
+	unit = strings.ToLower(unit)
+	for _, e := range textEncodings {
+		if e.unit == unit { return e, true }
+	}
+	return textEncoding{}, false
+}
+
+func addTextConversions(output *Conversions) {
+	for _, encoding := range textEncodings {
+		output.Add(Conversion{ "text", "text", encoding.unit, encoding.encode })
+		output.Add(Conversion{ "text", encoding.unit, "text", encoding.decode })
+	}
+}
@@ -0,0 +1,128 @@
+package conversions
+
+import (
+	"errors"
+	"strings"
+	"time"
+	_ "time/tzdata" // So that time zones can be loaded even when the system has no zoneinfo database
+)
+
+var wallClockLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"15:04:05",
+	"15:04",
+}
+
+const wallClockOutputLayout = "Mon 2006-01-02 15:04 MST"
+
+func loadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	// time.LoadLocation would return UTC for an empty name
+	if name == "" { return nil, errors.New("No time zone specified") }
+	if strings.ToLower(name) == "local" { return time.Local, nil }
+	if strings.ToLower(name) == "utc" { return time.UTC, nil }
+	location, err := time.LoadLocation(name)
+	if err != nil { return nil, errors.New("Unknown time zone: \"" + name + "\"") }
+	return location, nil
+}
+
+// Returns every instant at which the clocks in the given location show the wall-clock
+// time. There are two of them when the time is ambiguous (eg. when clocks go back at
+// the end of DST) and none when it does not exist (when clocks go forward).
+func wallClockInstants(wall time.Time, location *time.Location) []time.Time {
+	asUtc := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+	var output []time.Time
+	for _, probe := range []time.Duration{ -24 * time.Hour, 0, 24 * time.Hour } {
+		_, offset := asUtc.Add(probe).In(location).Zone()
+		candidate := asUtc.Add(-time.Duration(offset) * time.Second).In(location)
+		if candidate.Year() != wall.Year() || candidate.YearDay() != wall.YearDay() || candidate.Hour() != wall.Hour() || candidate.Minute() != wall.Minute() || candidate.Second() != wall.Second() { continue }
+		found := false
+		for _, t := range output {
+			if t.Equal(candidate) { found = true }
+		}
+		if !found { output = append(output, candidate) }
+	}
+	if len(output) == 2 && output[1].Before(output[0]) {
+		output[0], output[1] = output[1], output[0]
+	}
+	return output
+}
+
+// Resolves a wall-clock time in a location, following the "dst" option for times that
+// are ambiguous ("earlier", "later" or "error") or that do not exist ("error", or any
+// other value to move forward by the length of the gap). The returned note explains
+// how such a time was resolved.
+func (this *Conversions) resolveWallClock(wall time.Time, location *time.Location) (time.Time, string, error) {
+	dst := strings.ToLower(this.option("dst", "earlier"))
+	display := wall.Format("2006-01-02 15:04")
+	instants := wallClockInstants(wall, location)
+
+	if len(instants) == 2 {
+		if dst == "error" { return time.Time{}, "", errors.New(display + " is ambiguous in " + location.String() + ". Use --dst earlier or --dst later.") }
+		index := 0
+		if dst == "later" { index = 1 }
+		zone, _ := instants[index].Zone()
+		return instants[index], display + " occurs twice in " + location.String() + "; using " + zone + " (--dst " + [2]string{ "earlier", "later" }[index] + ").", nil
+	}
+
+	if len(instants) == 0 {
+		if dst == "error" { return time.Time{}, "", errors.New(display + " does not exist in " + location.String() + " because of a DST transition.") }
+		// Interpret the time with the offset in effect before the gap, which moves it
+		// forward by the length of the gap.
+		asUtc := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+		_, offset := asUtc.Add(-24 * time.Hour).In(location).Zone()
+		output := asUtc.Add(-time.Duration(offset) * time.Second).In(location)
+		return output, display + " does not exist in " + location.String() + " because of a DST transition; using " + output.Format("15:04 MST") + ".", nil
+	}
+
+	return instants[0], "", nil
+}
+
+// Converts a wall-clock time such as "2026-10-20 09:00" from one time zone to each of
+// the target time zones. Times without a date are assumed to be today in the source
+// time zone.
+func (this *Conversions) ConvertTimeZone(input string, from string, to []string) (time.Time, []time.Time, string, error) {
+	if strings.TrimSpace(from) == "" { return time.Time{}, nil, "", errors.New("No source time zone specified, eg. --from Europe/Paris") }
+	fromLocation, err := loadLocation(from)
+	if err != nil { return time.Time{}, nil, "", err }
+
+	var toLocations []*time.Location
+	for _, name := range to {
+		if strings.TrimSpace(name) == "" { continue }
+		location, err := loadLocation(name)
+		if err != nil { return time.Time{}, nil, "", err }
+		toLocations = append(toLocations, location)
+	}
+	if len(toLocations) == 0 { return time.Time{}, nil, "", errors.New("No target time zone specified, eg. --to America/New_York,Asia/Tokyo") }
+
+	wall, err := parseWithLayouts(input, wallClockLayouts, time.UTC)
+	if err != nil { return time.Time{}, nil, "", err }
+	if wall.Year() == 0 {
+		today := time.Now().In(fromLocation)
+		wall = time.Date(today.Year(), today.Month(), today.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, time.UTC)
+	}
+
+	source, note, err := this.resolveWallClock(wall, fromLocation)
+	if err != nil { return time.Time{}, nil, "", err }
+
+	var output []time.Time
+	for _, location := range toLocations {
+		output = append(output, source.In(location))
+	}
+	return source, output, note, nil
+}
+
+func (this *Conversions) ConvertTimeZoneFormat(format string, input string, from string, to []string) (string, error) {
+	source, results, note, err := this.ConvertTimeZone(input, from, to)
+	if err != nil { return "", err }
+	output := ""
+	for _, t := range results {
+		if output != "" { output += "\n" }
+		output += applyFormat(format, source.Format(wallClockOutputLayout), t.Format(wallClockOutputLayout), source.Location().String(), t.Location().String())
+	}
+	if note != "" { output += "\nNote: " + note }
+	return output, nil
+}
@@ -0,0 +1,367 @@
+package conversions
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reads TOML 1.0. Dates and times, which have no equivalent in JSON, are read as strings.
+type tomlParser struct {
+	s string
+	pos int
+	root *dataObject
+	// The tables that have had a header, which can't be repeated
+	defined map[*dataObject]bool
+	// The arrays made by "[[a]]" headers, which other arrays can't be extended like
+	tableArrays map[tomlTableKey]bool
+}
+
+// A key of a table
+type tomlTableKey struct {
+	table *dataObject
+	key string
+}
+
+func (this *tomlParser) errorf(message string) error {
+	line := strings.Count(this.s[:this.pos], "\n") + 1
+	return errors.New("Invalid TOML at line " + strconv.Itoa(line) + ": " + message)
+}
+
+func (this *tomlParser) peek() byte {
+	if this.pos >= len(this.s) { return 0 }
+	return this.s[this.pos]
+}
+
+func (this *tomlParser) skipSpace() {
+	for this.peek() == ' ' || this.peek() == '\t' {
+		this.pos++
+	}
+}
+
+// Skips spaces, line breaks and comments
+func (this *tomlParser) skipBlank() {
+	for {
+		this.skipSpace()
+		switch this.peek() {
+			case '#':
+				for this.pos < len(this.s) && this.s[this.pos] != '\n' {
+					this.pos++
+				}
+			case '\r', '\n':
+				this.pos++
+			default:
+				return
+		}
+	}
+}
+
+// Checks that nothing but a comment follows on the line
+func (this *tomlParser) parseLineEnd() error {
+	this.skipSpace()
+	if this.peek() == '#' {
+		for this.pos < len(this.s) && this.s[this.pos] != '\n' {
+			this.pos++
+		}
+	}
+	switch this.peek() {
+		case 0, '\n':
+			return nil
+		case '\r':
+			if strings.HasPrefix(this.s[this.pos:], "\r\n") { return nil }
+	}
+	return this.errorf("expected the end of the line")
+}
+
+func (this *tomlParser) parse() error {
+	table := this.root
+	for {
+		this.skipBlank()
+		if this.pos >= len(this.s) { return nil }
+		if this.peek() == '[' {
+			// "[[a]]" adds a table to the array of tables "a"
+			array := strings.HasPrefix(this.s[this.pos:], "[[")
+			end := "]"
+			if array { end = "]]" }
+			this.pos += len(end)
+			keys, err := this.parseKey()
+			if err != nil { return err }
+			if !strings.HasPrefix(this.s[this.pos:], end) { return this.errorf("expected \"" + end + "\"") }
+			this.pos += len(end)
+			table, err = this.table(keys, array)
+			if err != nil { return err }
+			if this.defined[table] { return this.errorf("table \"" + strings.Join(keys, ".") + "\" is defined twice") }
+			this.defined[table] = true
+		} else if err := this.parseKeyValue(table); err != nil {
+			return err
+		}
+		if err := this.parseLineEnd(); err != nil { return err }
+	}
+}
+
+// Returns the table of a "[a.b]" header, or the new table of a "[[a.b]]" header. The tables
+// before the last key are created if needed, and those that are arrays of tables stand for
+// their last table.
+func (this *tomlParser) table(keys []string, array bool) (*dataObject, error) {
+	table := this.root
+	for i, key := range keys {
+		value, exists := table.values[key]
+		last := i == len(keys) - 1
+		if !exists {
+			if last && array {
+				child := newDataObject()
+				table.set(key, []interface{}{ child })
+				this.tableArrays[tomlTableKey{ table, key }] = true
+				return child, nil
+			}
+			child := newDataObject()
+			table.set(key, child)
+			table = child
+			continue
+		}
+		switch v := value.(type) {
+			case *dataObject:
+				if last && array { return nil, this.errorf("\"" + strings.Join(keys, ".") + "\" is already a table") }
+				table = v
+			case []interface{}:
+				if !this.tableArrays[tomlTableKey{ table, key }] { return nil, this.errorf("\"" + strings.Join(keys[:i + 1], ".") + "\" is a static array, not an array of tables") }
+				if last && array {
+					child := newDataObject()
+					table.set(key, append(v, child))
+					return child, nil
+				}
+				var child *dataObject
+				if len(v) > 0 { child, _ = v[len(v) - 1].(*dataObject) }
+				if child == nil || last { return nil, this.errorf("\"" + strings.Join(keys[:i + 1], ".") + "\" is already an array") }
+				table = child
+			default:
+				return nil, this.errorf("\"" + strings.Join(keys[:i + 1], ".") + "\" is already a value")
+		}
+	}
+	return table, nil
+}
+
+// Parses a dotted key, eg. 'site."google.com".name'
+func (this *tomlParser) parseKey() ([]string, error) {
+	var output []string
+	for {
+		this.skipSpace()
+		switch c := this.peek(); {
+			case c == '"' || c == '\'':
+				key, err := this.parseString()
+				if err != nil { return nil, err }
+				output = append(output, key)
+			default:
+				start := this.pos
+				for c := this.peek(); c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'); c = this.peek() {
+					this.pos++
+				}
+				if start == this.pos { return nil, this.errorf("expected a key") }
+				output = append(output, this.s[start:this.pos])
+		}
+		this.skipSpace()
+		if this.peek() != '.' { return output, nil }
+		this.pos++
+	}
+}
+
+func (this *tomlParser) parseKeyValue(table *dataObject) error {
+	keys, err := this.parseKey()
+	if err != nil { return err }
+	if this.peek() != '=' { return this.errorf("expected \"=\" after the key") }
+	this.pos++
+	this.skipSpace()
+	value, err := this.parseValue()
+	if err != nil { return err }
+	// Dotted keys define the tables before the last key
+	for i, key := range keys[:len(keys) - 1] {
+		existing, exists := table.values[key]
+		if !exists {
+			child := newDataObject()
+			table.set(key, child)
+			table = child
+			continue
+		}
+		child, ok := existing.(*dataObject)
+		if !ok { return this.errorf("\"" + strings.Join(keys[:i + 1], ".") + "\" is already a value") }
+		table = child
+	}
+	key := keys[len(keys) - 1]
+	if _, exists := table.values[key]; exists { return this.errorf("\"" + strings.Join(keys, ".") + "\" is defined twice") }
+	table.set(key, value)
+	return nil
+}
+
+func (this *tomlParser) parseValue() (interface{}, error) {
+	switch c := this.peek(); {
+		case c == '"' || c == '\'':
+			return this.parseString()
+		case c == '[':
+			this.pos++
+			output := []interface{}{}
+			for {
+				this.skipBlank()
+				if this.peek() == ']' {
+					this.pos++
+					return output, nil
+				}
+				item, err := this.parseValue()
+				if err != nil { return nil, err }
+				output = append(output, item)
+				this.skipBlank()
+				if this.peek() == ',' {
+					this.pos++
+				} else if this.peek() != ']' {
+					return nil, this.errorf("expected \",\" or \"]\" in array")
+				}
+			}
+		case c == '{':
+			this.pos++
+			output := newDataObject()
+			this.skipSpace()
+			if this.peek() == '}' {
+				this.pos++
+				return output, nil
+			}
+			for {
+				this.skipSpace()
+				if err := this.parseKeyValue(output); err != nil { return nil, err }
+				this.skipSpace()
+				if this.peek() == '}' {
+					this.pos++
+					return output, nil
+				}
+				if this.peek() != ',' { return nil, this.errorf("expected \",\" or \"}\" in inline table") }
+				this.pos++
+			}
+	}
+
+	start := this.pos
+	for this.pos < len(this.s) && !strings.ContainsRune(" \t\r\n,]}#", rune(this.s[this.pos])) {
+		this.pos++
+	}
+	token := this.s[start:this.pos]
+	// Dates can be separated from times by a space
+	if len(token) == 10 && token[4] == '-' && strings.HasPrefix(this.s[this.pos:], " ") && len(this.s) > this.pos + 3 && this.s[this.pos + 3] == ':' {
+		this.pos++
+		for this.pos < len(this.s) && !strings.ContainsRune(" \t\r\n,]}#", rune(this.s[this.pos])) {
+			this.pos++
+		}
+		token = strings.Replace(this.s[start:this.pos], " ", "T", 1)
+	}
+	switch token {
+		case "": return nil, this.errorf("expected a value")
+		case "true": return true, nil
+		case "false": return false, nil
+		case "inf", "+inf": return math.Inf(1), nil
+		case "-inf": return math.Inf(-1), nil
+		case "nan", "+nan", "-nan": return math.NaN(), nil
+	}
+	if (len(token) >= 10 && token[4] == '-' && token[7] == '-') || (len(token) >= 8 && token[2] == ':') { return token, nil }
+
+	digits := strings.Replace(token, "_", "", -1)
+	if strings.Contains(token, "__") || strings.HasPrefix(token, "_") || strings.HasSuffix(token, "_") {
+		return nil, this.errorf("invalid number \"" + token + "\"")
+	}
+	for prefix, base := range map[string]int{ "0x": 16, "0o": 8, "0b": 2 } {
+		if strings.HasPrefix(digits, prefix) {
+			n, err := strconv.ParseInt(digits[2:], base, 64)
+			if err != nil { return nil, this.errorf("invalid number \"" + token + "\"") }
+			return n, nil
+		}
+	}
+	if !tomlNumberRegexp.MatchString(digits) {
+		if strings.Trim(digits, "+-0123456789.eE") == "" { return nil, this.errorf("invalid number \"" + token + "\"") }
+		return nil, this.errorf("invalid value \"" + token + "\"")
+	}
+	// Only numbers with a fraction or an exponent are floats
+	if !strings.ContainsAny(digits, ".eE") {
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil { return nil, this.errorf("invalid integer \"" + token + "\"") }
+		return n, nil
+	}
+	n, err := strconv.ParseFloat(digits, 64)
+	if err != nil { return nil, this.errorf("invalid number \"" + token + "\"") }
+	return n, nil
+}
+
+// Decimal numbers, without leading zeros, and with digits on both sides of the point
+var tomlNumberRegexp = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// Parses a basic ("...") or literal ('...') string, either of which can span several
+// lines when its quotes are tripled
+func (this *tomlParser) parseString() (string, error) {
+	quote := this.s[this.pos]
+	delimiter := string(quote)
+	if strings.HasPrefix(this.s[this.pos:], strings.Repeat(delimiter, 3)) {
+		delimiter = strings.Repeat(delimiter, 3)
+	}
+	multiline := len(delimiter) == 3
+	this.pos += len(delimiter)
+	// A line break right after the opening quotes is trimmed
+	if multiline && strings.HasPrefix(this.s[this.pos:], "\n") {
+		this.pos++
+	} else if multiline && strings.HasPrefix(this.s[this.pos:], "\r\n") {
+		this.pos += 2
+	}
+
+	var output strings.Builder
+	for this.pos < len(this.s) {
+		if strings.HasPrefix(this.s[this.pos:], delimiter) {
+			this.pos += len(delimiter)
+			// Up to two quotes can precede the closing ones
+			for i := 0; multiline && i < 2 && this.peek() == quote; i++ {
+				output.WriteByte(quote)
+				this.pos++
+			}
+			return output.String(), nil
+		}
+		c := this.s[this.pos]
+		this.pos++
+		if c == '\n' && !multiline { break }
+		if c != '\\' || quote == '\'' {
+			output.WriteByte(c)
+			continue
+		}
+		e := this.peek()
+		this.pos++
+		switch e {
+			case 'b': output.WriteByte('\b')
+			case 't': output.WriteByte('\t')
+			case 'n': output.WriteByte('\n')
+			case 'f': output.WriteByte('\f')
+			case 'r': output.WriteByte('\r')
+			case 'e': output.WriteByte('\x1b')
+			case '"': output.WriteByte('"')
+			case '\\': output.WriteByte('\\')
+			case 'u', 'U':
+				size := 4
+				if e == 'U' { size = 8 }
+				if this.pos + size > len(this.s) { return "", this.errorf("invalid escape sequence") }
+				code, err := strconv.ParseUint(this.s[this.pos:this.pos + size], 16, 32)
+				if err != nil { return "", this.errorf("invalid escape sequence \"\\" + this.s[this.pos - 1:this.pos + size] + "\"") }
+				output.WriteRune(rune(code))
+				this.pos += size
+			default:
+				// A backslash at the end of a line trims the line break and the whitespace after it
+				rest := strings.TrimLeft(this.s[this.pos - 1:], " \t")
+				if !multiline || !(strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n")) {
+					return "", this.errorf("invalid escape sequence \"\\" + string(e) + "\"")
+				}
+				this.pos = len(this.s) - len(strings.TrimLeft(rest, " \t\r\n"))
+		}
+	}
+	return "", this.errorf("unterminated string")
+}
+
+func (this *Conversions) readToml(input io.Reader) (interface{}, error) {
+	data, err := ioutil.ReadAll(input)
+	if err != nil { return nil, err }
+	parser := tomlParser{ strings.TrimPrefix(string(data), "\ufeff"), 0, newDataObject(), map[*dataObject]bool{}, map[tomlTableKey]bool{} }
+	if err := parser.parse(); err != nil { return nil, err }
+	return parser.root, nil
+}
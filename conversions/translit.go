@@ -0,0 +1,293 @@
+package conversions
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Cyrillic letters and their ISO 9:1995 transliteration, which maps each letter to a
+// single Latin letter so that it can be reversed
+var iso9Letters = []struct {
+	cyrillic rune
+	latin string
+}{
+	{ 'А', "A" }, { 'Б', "B" }, { 'В', "V" }, { 'Г', "G" }, { 'Ґ', "G̀" }, { 'Д', "D" }, { 'Ѓ', "Ǵ" }, { 'Ђ', "Đ" },
+	{ 'Е', "E" }, { 'Ё', "Ë" }, { 'Є', "Ê" }, { 'Ж', "Ž" }, { 'З', "Z" }, { 'Ѕ', "Ẑ" }, { 'И', "I" }, { 'І', "Ì" },
+	{ 'Ї', "Ï" }, { 'Й', "J" }, { 'Ј', "J̌" }, { 'К', "K" }, { 'Л', "L" }, { 'Љ', "L̂" }, { 'М', "M" }, { 'Н', "N" },
+	{ 'Њ', "N̂" }, { 'О', "O" }, { 'П', "P" }, { 'Р', "R" }, { 'С', "S" }, { 'Т', "T" }, { 'Ќ', "Ḱ" }, { 'Ћ', "Ć" },
+	{ 'У', "U" }, { 'Ў', "Ŭ" }, { 'Ф', "F" }, { 'Х', "H" }, { 'Ц', "C" }, { 'Ч', "Č" }, { 'Џ', "D̂" }, { 'Ш', "Š" },
+	{ 'Щ', "Ŝ" }, { 'Ъ', "ʺ" }, { 'Ы', "Y" }, { 'Ь', "ʹ" }, { 'Э', "È" }, { 'Ю', "Û" }, { 'Я', "Â" }, { 'Ѣ', "Ě" },
+	{ 'Ѫ', "Ǎ" }, { 'Ѳ', "F̀" }, { 'Ѵ', "Ỳ" },
+}
+
+// Applies the case of the source letters to their transliteration. A capital followed or
+// preceded by another capital is part of a word in capitals, so "Щ" is "Shch" in "Щука"
+// but "SHCH" in "ЩУКА".
+func transliterationCase(latin string, source []rune, i int, n int) string {
+	if !unicode.IsUpper(source[i]) { return latin }
+	if (i + n < len(source) && unicode.IsUpper(source[i + n])) || (i > 0 && unicode.IsUpper(source[i - 1])) {
+		return strings.ToUpper(latin)
+	}
+	return capitalize(latin)
+}
+
+func cyrillicToIso9(input string) string {
+	letters := make(map[rune]string)
+	for _, l := range iso9Letters {
+		letters[l.cyrillic] = l.latin
+		letters[unicode.ToLower(l.cyrillic)] = strings.ToLower(l.latin)
+	}
+	var output strings.Builder
+	for _, r := range input {
+		if latin, ok := letters[r]; ok {
+			output.WriteString(latin)
+		} else {
+			output.WriteRune(r)
+		}
+	}
+	return output.String()
+}
+
+func iso9ToCyrillic(input string) string {
+	letters := make(map[string]rune)
+	for _, l := range iso9Letters {
+		letters[strings.ToLower(l.latin)] = unicode.ToLower(l.cyrillic)
+		if l.latin != strings.ToLower(l.latin) { letters[l.latin] = l.cyrillic }
+	}
+	runes := []rune(input)
+	var output strings.Builder
+	for i := 0; i < len(runes); {
+		// Some letters are a base letter followed by a combining mark, so the longest match wins
+		n := 2
+		for ; n > 0; n-- {
+			if i + n > len(runes) { continue }
+			if cyrillic, ok := letters[string(runes[i:i + n])]; ok {
+				// The hard and soft signs have no case, they follow the letter before them
+				if (cyrillic == 'ъ' || cyrillic == 'ь') && i > 0 && unicode.IsUpper(runes[i - 1]) { cyrillic = unicode.ToUpper(cyrillic) }
+				output.WriteRune(cyrillic)
+				break
+			}
+		}
+		if n == 0 {
+			output.WriteRune(runes[i])
+			n = 1
+		}
+		i += n
+	}
+	return output.String()
+}
+
+var bgnLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ʺ", 'ы': "y", 'ь': "ʹ",
+	'э': "e", 'ю': "yu", 'я': "ya",
+	// Letters of Ukrainian and Belarusian that aren't in the Russian alphabet
+	'ґ': "g", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "w",
+}
+
+// Pairs of letters that are separated by a middle dot, so that they aren't read as a
+// single letter, eg. "тс" is "t·s" rather than "ts" which is "ц"
+var bgnSeparatedPairs = []string{ "тс", "шч", "зх", "кх", "сх", "цх", "йа", "йу", "йы", "йэ", "ыа", "ыу", "ыы", "ыэ" }
+
+// Transliterates Russian with the BGN/PCGN 1947 system. "Е" and "Ё" are written "ye"
+// and "yë" at the start of a word and after vowels and signs.
+func cyrillicToBgn(input string) string {
+	runes := []rune(input)
+	var output strings.Builder
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+		latin, ok := bgnLetters[lower]
+		if !ok {
+			output.WriteRune(r)
+			continue
+		}
+		if lower == 'е' || lower == 'ё' {
+			if i == 0 || strings.ContainsRune("аеёиоуыэюяіїєйъь", unicode.ToLower(runes[i - 1])) || !unicode.IsLetter(runes[i - 1]) {
+				latin = "y" + latin
+			}
+		}
+		output.WriteString(transliterationCase(latin, runes, i, 1))
+		if i + 1 < len(runes) {
+			for _, pair := range bgnSeparatedPairs {
+				if string([]rune{ lower, unicode.ToLower(runes[i + 1]) }) == pair { output.WriteString("·") }
+			}
+		}
+	}
+	return output.String()
+}
+
+var elotLetters = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Vowels with a tonos or a dialytika, and the vowel without them
+var greekAccents = map[rune]rune{
+	'ά': 'α', 'έ': 'ε', 'ή': 'η', 'ί': 'ι', 'ό': 'ο', 'ύ': 'υ', 'ώ': 'ω', 'ϊ': 'ι', 'ϋ': 'υ', 'ΐ': 'ι', 'ΰ': 'υ',
+}
+
+// Transliterates Greek with ELOT 743, the system of Greek passports and road signs.
+// Accents are dropped, and the digraphs are transliterated by their pronunciation,
+// eg. "αυ" is "av" before a voiced sound and "af" before a voiceless one.
+func greekToElot(input string) string {
+	source := []rune(input)
+	base := make([]rune, len(source))
+	dialytika := make([]bool, len(source))
+	for i, r := range source {
+		base[i] = unicode.ToLower(r)
+		if unaccented, ok := greekAccents[base[i]]; ok {
+			dialytika[i] = strings.ContainsRune("ϊϋΐΰ", base[i])
+			base[i] = unaccented
+		}
+	}
+	isLetter := func(i int) bool { return i >= 0 && i < len(base) && elotLetters[base[i]] != "" }
+	at := func(i int) rune {
+		if i < len(base) { return base[i] }
+		return 0
+	}
+
+	var output strings.Builder
+	for i := 0; i < len(base); {
+		latin, ok := elotLetters[base[i]]
+		if !ok {
+			// The Greek question mark looks like a semicolon
+			if source[i] == ';' { source[i] = '?' }
+			output.WriteRune(source[i])
+			i++
+			continue
+		}
+		n := 1
+		next := at(i + 1)
+		switch {
+			case strings.ContainsRune("αεη", base[i]) && next == 'υ' && !dialytika[i + 1]:
+				if isLetter(i + 2) && strings.ContainsRune("αεηιουωβγδζλμνρ", at(i + 2)) {
+					latin += "v"
+				} else {
+					latin += "f"
+				}
+				n = 2
+			case base[i] == 'ο' && next == 'υ' && !dialytika[i + 1]:
+				latin, n = "ou", 2
+			case base[i] == 'γ' && next == 'γ':
+				latin, n = "ng", 2
+			case base[i] == 'γ' && next == 'ξ':
+				latin, n = "nx", 2
+			case base[i] == 'γ' && next == 'χ':
+				latin, n = "nch", 2
+			case base[i] == 'μ' && next == 'π':
+				// "μπ" is "b" at the start and the end of words
+				latin, n = "mp", 2
+				if !isLetter(i - 1) || !isLetter(i + 2) { latin = "b" }
+		}
+		output.WriteString(transliterationCase(latin, source, i, n))
+		i += n
+	}
+	return output.String()
+}
+
+// Latin letters with diacritics, and their ASCII equivalents
+var asciiFolding = []struct {
+	ascii string
+	letters string
+}{
+	{ "A", "ÀÁÂÃÄÅĀĂĄǍ" }, { "a", "àáâãäåāăąǎª" }, { "AE", "Æ" }, { "ae", "æ" }, { "C", "ÇĆĈĊČ" }, { "c", "çćĉċč" },
+	{ "D", "ĎĐÐ" }, { "d", "ďđð" }, { "E", "ÈÉÊËĒĔĖĘĚ" }, { "e", "èéêëēĕėęě" }, { "G", "ĜĞĠĢǦǴ" }, { "g", "ĝğġģǧǵ" },
+	{ "H", "ĤĦ" }, { "h", "ĥħ" }, { "I", "ÌÍÎÏĨĪĬĮİǏ" }, { "i", "ìíîïĩīĭįıǐ" }, { "IJ", "Ĳ" }, { "ij", "ĳ" },
+	{ "J", "Ĵ" }, { "j", "ĵǰ" }, { "K", "ĶḰ" }, { "k", "ķĸḱ" }, { "L", "ĹĻĽĿŁ" }, { "l", "ĺļľŀł" },
+	{ "N", "ÑŃŅŇŊ" }, { "n", "ñńņňŉŋ" }, { "O", "ÒÓÔÕÖØŌŎŐƠǑ" }, { "o", "òóôõöøōŏőơǒº" }, { "OE", "Œ" }, { "oe", "œ" },
+	{ "R", "ŔŖŘ" }, { "r", "ŕŗř" }, { "S", "ŚŜŞŠȘ" }, { "s", "śŝşšșſ" }, { "ss", "ß" }, { "T", "ŢŤŦȚ" }, { "t", "ţťŧț" },
+	{ "TH", "Þ" }, { "th", "þ" }, { "U", "ÙÚÛÜŨŪŬŮŰŲƯǓ" }, { "u", "ùúûüũūŭůűųưǔ" }, { "W", "Ŵ" }, { "w", "ŵ" },
+	{ "Y", "ÝŶŸ" }, { "y", "ýÿŷ" }, { "Z", "ŹŻŽẐ" }, { "z", "źżžẑ" }, { "f", "ƒ" },
+}
+
+// Removes the diacritics of Latin letters. Combining marks are dropped, so decomposed
+// text is folded too.
+func foldToAscii(input string) string {
+	folding := make(map[rune]string)
+	for _, f := range asciiFolding {
+		for _, r := range f.letters {
+			folding[r] = f.ascii
+		}
+	}
+	var output strings.Builder
+	for _, r := range input {
+		switch {
+			case unicode.Is(unicode.Mn, r):
+				continue
+			// The Vietnamese letters of the Latin Extended Additional block alternate between
+			// capital and small letters, grouped by base letter
+			case r >= 0x1ea0 && r <= 0x1ef9:
+				letter := "Y"
+				for _, group := range []struct { end rune; letter string }{ { 0x1eb7, "A" }, { 0x1ec7, "E" }, { 0x1ecb, "I" }, { 0x1ee3, "O" }, { 0x1ef1, "U" } } {
+					if r <= group.end {
+						letter = group.letter
+						break
+					}
+				}
+				if r % 2 == 1 { letter = strings.ToLower(letter) }
+				output.WriteString(letter)
+			default:
+				if ascii, ok := folding[r]; ok {
+					output.WriteString(ascii)
+				} else {
+					output.WriteRune(r)
+				}
+		}
+	}
+	return output.String()
+}
+
+// Makes a URL slug or a username from text in any of the supported scripts. Cyrillic is
+// transliterated with BGN/PCGN and Greek with ELOT 743, as they only need ASCII letters.
+func (this *Conversions) slugify(input string) (string, error) {
+	separator := this.option("slug-separator", "-")
+	folded := strings.ToLower(foldToAscii(greekToElot(cyrillicToBgn(input))))
+	var words []string
+	word := ""
+	for _, r := range folded {
+		switch {
+			case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+				word += string(r)
+			// Apostrophes and transliteration marks are part of the word, eg. "o'brien" is "obrien"
+			case strings.ContainsRune("'’`´ʹʺ·", r):
+				continue
+			default:
+				if word != "" { words = append(words, word) }
+				word = ""
+		}
+	}
+	if word != "" { words = append(words, word) }
+	if len(words) == 0 { return "", errors.New("Nothing to make a slug from, the text has no letters or digits that can be written in ASCII: \"" + input + "\"") }
+	return strings.Join(words, separator), nil
+}
+
+func addTransliterationConversions(output *Conversions) {
+	output.Add(Conversion{
+		"translit", "cyrillic", "iso9", func(input string) (string, error) {
+			return cyrillicToIso9(input), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"translit", "iso9", "cyrillic", func(input string) (string, error) {
+			return iso9ToCyrillic(input), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"translit", "cyrillic", "bgn", func(input string) (string, error) {
+			return cyrillicToBgn(input), nil
+		},
+	})
+
+	output.Add(Conversion{
+		"translit", "greek", "elot", func(input string) (string, error) {
+			return greekToElot(input), nil
+		},
+	})
+
+	output.Add(Conversion{ "translit", "text", "slug", output.slugify })
+}
@@ -0,0 +1,64 @@
+package conversions
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type typographyUnit struct {
+	unit string
+	name string
+	// Returns the number of pixels in one unit
+	pixels func(dpi float64, rootFontSize float64, fontScale float64) float64
+}
+
+var typographyUnits = []typographyUnit{
+	{ "px", "Pixel", func(dpi, rootFontSize, fontScale float64) float64 { return 1 } },
+	{ "pt", "Point", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 72 } },
+	{ "pc", "Pica", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 6 } },
+	{ "in", "Inch", func(dpi, rootFontSize, fontScale float64) float64 { return dpi } },
+	{ "cm", "Centimetre", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 2.54 } },
+	{ "mm", "Millimetre", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 25.4 } },
+	{ "q", "Quarter-millimetre", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 101.6 } },
+	{ "em", "Em (relative to the root font size)", func(dpi, rootFontSize, fontScale float64) float64 { return rootFontSize } },
+	{ "rem", "Root Em", func(dpi, rootFontSize, fontScale float64) float64 { return rootFontSize } },
+	{ "dp", "Android Density-independent Pixel", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 160 } },
+	{ "sp", "Android Scale-independent Pixel", func(dpi, rootFontSize, fontScale float64) float64 { return dpi / 160 * fontScale } },
+}
+
+func findTypographyUnit(unit string) (typographyUnit, bool) {
+	unit = strings.ToLower(unit)
+	for _, u := range typographyUnits {
+		if u.unit == unit { return u, true }
+	}
+	return typographyUnit{}, false
+}
+
+func addTypographyConversions(output *Conversions) {
+	typographyConv := func(input string, from typographyUnit, to typographyUnit) (string, error) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil { return "", err }
+		dpi, err := output.optionFloat("dpi", 96)
+		if err != nil { return "", err }
+		rootFontSize, err := output.optionFloat("root-font-size", 16)
+		if err != nil { return "", err }
+		fontScale, err := output.optionFloat("font-scale", 1)
+		if err != nil { return "", err }
+		if dpi <= 0 || rootFontSize <= 0 || fontScale <= 0 { return "", errors.New("DPI, root font size and font scale must be greater than zero") }
+		pixels := value * from.pixels(dpi, rootFontSize, fontScale)
+		return formatFloat(pixels / to.pixels(dpi, rootFontSize, fontScale)), nil
+	}
+
+	for _, from := range typographyUnits {
+		for _, to := range typographyUnits {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"typography", from.unit, to.unit, func(input string) (string, error) {
+					return typographyConv(input, from, to)
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package conversions
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Exponents of the SI base dimensions, in the order m, kg, s, A, K, mol, cd
+type dimension [7]int
+
+var dimensionSymbols = []string{"m", "kg", "s", "A", "K", "mol", "cd"}
+
+func (this dimension) String() string {
+	s := ""
+	for i, e := range this {
+		if e == 0 { continue }
+		if s != "" { s += "*" }
+		s += dimensionSymbols[i]
+		if e != 1 { s += "^" + strconv.Itoa(e) }
+	}
+	if s == "" { return "1" }
+	return s
+}
+
+type quantity struct {
+	factor float64
+	dim dimension
+}
+
+func (this quantity) multiply(other quantity) quantity {
+	output := quantity{ this.factor * other.factor, this.dim }
+	for i := range output.dim {
+		output.dim[i] += other.dim[i]
+	}
+	return output
+}
+
+func (this quantity) power(exponent int) quantity {
+	output := quantity{ math.Pow(this.factor, float64(exponent)), dimension{} }
+	for i := range output.dim {
+		output.dim[i] = this.dim[i] * exponent
+	}
+	return output
+}
+
+type unitDef struct {
+	name string
+	factor float64
+	dim dimension
+	prefixable bool
+}
+
+var (
+	dimLength = dimension{1, 0, 0, 0, 0, 0, 0}
+	dimMass = dimension{0, 1, 0, 0, 0, 0, 0}
+	dimTime = dimension{0, 0, 1, 0, 0, 0, 0}
+	dimCurrent = dimension{0, 0, 0, 1, 0, 0, 0}
+	dimTemperature = dimension{0, 0, 0, 0, 1, 0, 0}
+	dimAmount = dimension{0, 0, 0, 0, 0, 1, 0}
+	dimLuminosity = dimension{0, 0, 0, 0, 0, 0, 1}
+	dimArea = dimension{2, 0, 0, 0, 0, 0, 0}
+	dimVolume = dimension{3, 0, 0, 0, 0, 0, 0}
+	dimFrequency = dimension{0, 0, -1, 0, 0, 0, 0}
+	dimSpeed = dimension{1, 0, -1, 0, 0, 0, 0}
+	dimForce = dimension{1, 1, -2, 0, 0, 0, 0}
+	dimEnergy = dimension{2, 1, -2, 0, 0, 0, 0}
+	dimPower = dimension{2, 1, -3, 0, 0, 0, 0}
+	dimPressure = dimension{-1, 1, -2, 0, 0, 0, 0}
+	dimCharge = dimension{0, 0, 1, 1, 0, 0, 0}
+	dimVoltage = dimension{2, 1, -3, -1, 0, 0, 0}
+	dimResistance = dimension{2, 1, -3, -2, 0, 0, 0}
+	dimCapacitance = dimension{-2, -1, 4, 2, 0, 0, 0}
+	dimInductance = dimension{2, 1, -2, -2, 0, 0, 0}
+	dimMagneticFlux = dimension{2, 1, -2, -1, 0, 0, 0}
+	dimMagneticField = dimension{0, 1, -2, -1, 0, 0, 0}
+)
+
+var unitDefs = map[string]unitDef{
+	"m": { "Metre", 1, dimLength, true },
+	"in": { "Inch", 0.0254, dimLength, false },
+	"ft": { "Foot", 0.3048, dimLength, false },
+	"yd": { "Yard", 0.9144, dimLength, false },
+	"mi": { "Mile", 1609.344, dimLength, false },
+	"nmi": { "Nautical Mile", 1852, dimLength, false },
+	"au": { "Astronomical Unit", 149597870700, dimLength, false },
+	"ly": { "Light Year", 9460730472580800, dimLength, false },
+	"pc": { "Parsec", 3.0856775814913673e16, dimLength, true },
+	"g": { "Gram", 1e-3, dimMass, true },
+	"t": { "Tonne", 1000, dimMass, false },
+	"lb": { "Pound", 0.45359237, dimMass, false },
+	"oz": { "Ounce", 0.028349523125, dimMass, false },
+	"st": { "Stone", 6.35029318, dimMass, false },
+	"s": { "Second", 1, dimTime, true },
+	"min": { "Minute", 60, dimTime, false },
+	"h": { "Hour", 3600, dimTime, false },
+	"d": { "Day", 86400, dimTime, false },
+	"wk": { "Week", 604800, dimTime, false },
+	"yr": { "Year", 31557600, dimTime, false },
+	"A": { "Ampere", 1, dimCurrent, true },
+	"K": { "Kelvin", 1, dimTemperature, true },
+	"mol": { "Mole", 1, dimAmount, true },
+	"cd": { "Candela", 1, dimLuminosity, true },
+	"ha": { "Hectare", 1e4, dimArea, false },
+	"ac": { "Acre", 4046.8564224, dimArea, false },
+	"L": { "Litre", 1e-3, dimVolume, true },
+	"l": { "Litre", 1e-3, dimVolume, true },
+	"gal": { "US Gallon", 3.785411784e-3, dimVolume, false },
+	"Hz": { "Hertz", 1, dimFrequency, true },
+	"mph": { "Mile per Hour", 0.44704, dimSpeed, false },
+	"kn": { "Knot", 1852.0 / 3600.0, dimSpeed, false },
+	"N": { "Newton", 1, dimForce, true },
+	"lbf": { "Pound-force", 4.4482216152605, dimForce, false },
+	"J": { "Joule", 1, dimEnergy, true },
+	"cal": { "Calorie", 4.184, dimEnergy, true },
+	"eV": { "Electronvolt", 1.602176634e-19, dimEnergy, true },
+	"Wh": { "Watt-hour", 3600, dimEnergy, true },
+	"W": { "Watt", 1, dimPower, true },
+	"hp": { "Horsepower", 745.69987158227022, dimPower, false },
+	"Pa": { "Pascal", 1, dimPressure, true },
+	"bar": { "Bar", 1e5, dimPressure, true },
+	"atm": { "Atmosphere", 101325, dimPressure, false },
+	"psi": { "Pound per Square Inch", 6894.757293168, dimPressure, false },
+	"mmHg": { "Millimetre of Mercury", 133.322387415, dimPressure, false },
+	"C": { "Coulomb", 1, dimCharge, true },
+	"V": { "Volt", 1, dimVoltage, true },
+	"ohm": { "Ohm", 1, dimResistance, true },
+	"Ω": { "Ohm", 1, dimResistance, true },
+	"F": { "Farad", 1, dimCapacitance, true },
+	"H": { "Henry", 1, dimInductance, true },
+	"Wb": { "Weber", 1, dimMagneticFlux, true },
+	"T": { "Tesla", 1, dimMagneticField, true },
+}
+
+// Longer prefixes first so that "da" is tried before "d"
+var unitPrefixes = []struct {
+	symbol string
+	factor float64
+}{
+	{ "da", 1e1 },
+	{ "Y", 1e24 }, { "Z", 1e21 }, { "E", 1e18 }, { "P", 1e15 }, { "T", 1e12 },
+	{ "G", 1e9 }, { "M", 1e6 }, { "k", 1e3 }, { "h", 1e2 }, { "d", 1e-1 },
+	{ "c", 1e-2 }, { "m", 1e-3 }, { "µ", 1e-6 }, { "u", 1e-6 }, { "n", 1e-9 },
+	{ "p", 1e-12 }, { "f", 1e-15 }, { "a", 1e-18 }, { "z", 1e-21 }, { "y", 1e-24 },
+}
+
+func lookupUnit(name string) (quantity, error) {
+	if u, ok := unitDefs[name]; ok {
+		return quantity{ u.factor, u.dim }, nil
+	}
+	for _, p := range unitPrefixes {
+		if !strings.HasPrefix(name, p.symbol) { continue }
+		u, ok := unitDefs[name[len(p.symbol):]]
+		if !ok || !u.prefixable { continue }
+		return quantity{ p.factor * u.factor, u.dim }, nil
+	}
+	return quantity{}, errors.New("Unknown unit: \"" + name + "\"")
+}
+
+var superscripts = map[rune]rune{
+	'⁻': '-', '⁰': '0', '¹': '1', '²': '2', '³': '3', '⁴': '4',
+	'⁵': '5', '⁶': '6', '⁷': '7', '⁸': '8', '⁹': '9',
+}
+
+type expressionParser struct {
+	input []rune
+	pos int
+}
+
+func (this *expressionParser) skipSpaces() {
+	for this.pos < len(this.input) && unicode.IsSpace(this.input[this.pos]) {
+		this.pos++
+	}
+}
+
+func (this *expressionParser) peek() rune {
+	this.skipSpaces()
+	if this.pos >= len(this.input) { return 0 }
+	return this.input[this.pos]
+}
+
+func (this *expressionParser) errorAt(message string) error {
+	return errors.New(message + " at position " + strconv.Itoa(this.pos + 1) + " in \"" + string(this.input) + "\"")
+}
+
+// expression := term (("*" | "·" | "/") term)*
+func (this *expressionParser) expression() (quantity, error) {
+	output, err := this.term()
+	if err != nil { return output, err }
+	for {
+		op := this.peek()
+		if op != '*' && op != '·' && op != '/' { return output, nil }
+		this.pos++
+		right, err := this.term()
+		if err != nil { return output, err }
+		if op == '/' { right = right.power(-1) }
+		output = output.multiply(right)
+	}
+}
+
+// term := primary ("^" integer | superscript integer)?
+func (this *expressionParser) term() (quantity, error) {
+	output, err := this.primary()
+	if err != nil { return output, err }
+	if this.peek() == '^' {
+		this.pos++
+		this.skipSpaces()
+		start := this.pos
+		if this.pos < len(this.input) && (this.input[this.pos] == '-' || this.input[this.pos] == '+') { this.pos++ }
+		for this.pos < len(this.input) && unicode.IsDigit(this.input[this.pos]) {
+			this.pos++
+		}
+		exponent, err := this.exponent(string(this.input[start:this.pos]))
+		if err != nil { return output, err }
+		return output.power(exponent), nil
+	}
+	s := ""
+	for this.pos < len(this.input) {
+		r, ok := superscripts[this.input[this.pos]]
+		if !ok { break }
+		s += string(r)
+		this.pos++
+	}
+	if s != "" {
+		exponent, err := this.exponent(s)
+		if err != nil { return output, err }
+		return output.power(exponent), nil
+	}
+	return output, nil
+}
+
+// Exponents are limited to a range that units can make sense with
+const maxUnitExponent = 32
+
+func (this *expressionParser) exponent(s string) (int, error) {
+	exponent, err := strconv.Atoi(s)
+	if err != nil { return 0, this.errorAt("Invalid exponent") }
+	if exponent > maxUnitExponent || exponent < -maxUnitExponent { return 0, this.errorAt("Exponent out of range") }
+	return exponent, nil
+}
+
+// primary := unit | number | "(" expression ")"
+func (this *expressionParser) primary() (quantity, error) {
+	c := this.peek()
+	if c == 0 { return quantity{}, this.errorAt("Unexpected end of expression") }
+
+	if c == '(' {
+		this.pos++
+		output, err := this.expression()
+		if err != nil { return output, err }
+		if this.peek() != ')' { return output, this.errorAt("Missing closing parenthesis") }
+		this.pos++
+		return output, nil
+	}
+
+	start := this.pos
+	if unicode.IsDigit(c) || c == '.' {
+		for this.pos < len(this.input) && (unicode.IsDigit(this.input[this.pos]) || this.input[this.pos] == '.') {
+			this.pos++
+		}
+		n, err := strconv.ParseFloat(string(this.input[start:this.pos]), 64)
+		if err != nil { return quantity{}, this.errorAt("Invalid number") }
+		return quantity{ n, dimension{} }, nil
+	}
+
+	for this.pos < len(this.input) && unicode.IsLetter(this.input[this.pos]) {
+		this.pos++
+	}
+	if this.pos == start { return quantity{}, this.errorAt("Unexpected character '" + string(c) + "'") }
+	return lookupUnit(string(this.input[start:this.pos]))
+}
+
+func parseUnitExpression(expression string) (quantity, error) {
+	p := &expressionParser{ []rune(expression), 0 }
+	output, err := p.expression()
+	if err != nil { return output, err }
+	if p.peek() != 0 { return output, p.errorAt("Unexpected character '" + string(p.peek()) + "'") }
+	return output, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 12, 64)
+}
+
+// Converts a value between two unit expressions such as "kg*m/s^2" and "N". Both
+// expressions must resolve to the same dimension.
+func (this *Conversions) ConvertExpression(from string, to string, input string) (string, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+	if err != nil { return "", err }
+	qFrom, err := parseUnitExpression(from)
+	if err != nil { return "", err }
+	qTo, err := parseUnitExpression(to)
+	if err != nil { return "", err }
+	if qFrom.dim != qTo.dim {
+		return "", errors.New("Incompatible units: \"" + from + "\" is " + qFrom.dim.String() + " but \"" + to + "\" is " + qTo.dim.String())
+	}
+	return formatFloat(value * qFrom.factor / qTo.factor), nil
+}
+
+func (this *Conversions) ConvertExpressionFormat(format string, from string, to string, input string) (string, error) {
+	result, err := this.ConvertExpression(from, to, input)
+	if err != nil { return result, err }
+	return applyFormat(format, input, result, from, to), nil
+}
+
+func (this *Conversions) ExpressionUnitNames() []string {
+	var output []string
+	for name := range unitDefs {
+		output = append(output, name)
+	}
+	sort.Strings(output)
+	return output
+}
+
+func (this *Conversions) NiceExpressionUnitName(s string) string {
+	u, ok := unitDefs[s]
+	if !ok { return s }
+	if u.prefixable { return u.name + " (SI prefixes allowed)" }
+	return u.name
+}
@@ -0,0 +1,189 @@
+package conversions
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func encodeBase58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(58)
+	mod := new(big.Int)
+	output := ""
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		output = string(base58Alphabet[mod.Int64()]) + output
+	}
+	for _, c := range b {
+		if c != 0 { break }
+		output = "1" + output
+	}
+	return output
+}
+
+func decodeBase58(s string, size int) ([]byte, error) {
+	n := new(big.Int)
+	base := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 { return nil, errors.New("Invalid base58 character: '" + string(c) + "'") }
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	if n.BitLen() > size * 8 { return nil, errors.New("Base58 value is too large: \"" + s + "\"") }
+	return n.FillBytes(make([]byte, size)), nil
+}
+
+// Swaps the byte order of the first three fields, to convert between the RFC 9562
+// byte layout and the one Microsoft uses to store GUIDs.
+func swapGuidBytes(u [16]byte) [16]byte {
+	output := u
+	output[0], output[1], output[2], output[3] = u[3], u[2], u[1], u[0]
+	output[4], output[5] = u[5], u[4]
+	output[6], output[7] = u[7], u[6]
+	return output
+}
+
+func parseUuidHex(input string) ([16]byte, error) {
+	var output [16]byte
+	b, err := hex.DecodeString(strings.TrimSpace(input))
+	if err != nil || len(b) != 16 { return output, errors.New("Invalid value, expected 32 hexadecimal digits: \"" + input + "\"") }
+	copy(output[:], b)
+	return output, nil
+}
+
+type uuidFormat struct {
+	unit string
+	name string
+	parse func(input string) ([16]byte, error)
+	format func(u [16]byte) string
+}
+
+var uuidFormats = []uuidFormat{
+	{ "uuid", "UUID", parseUuid, formatUuid },
+	{ "uuidhex", "Compact Hexadecimal UUID", parseUuidHex, func(u [16]byte) string { return hex.EncodeToString(u[:]) } },
+	{ "uuidbraces", "Braced UUID", parseUuid, func(u [16]byte) string { return "{" + formatUuid(u) + "}" } },
+	{ "uuidurn", "UUID URN", parseUuid, func(u [16]byte) string { return "urn:uuid:" + formatUuid(u) } },
+	{
+		"uuid64", "Short Base64 UUID (URL-safe, no padding)",
+		func(input string) ([16]byte, error) {
+			var output [16]byte
+			s := strings.TrimRight(strings.TrimSpace(input), "=")
+			s = strings.Replace(strings.Replace(s, "+", "-", -1), "/", "_", -1)
+			b, err := base64.RawURLEncoding.DecodeString(s)
+			if err != nil || len(b) != 16 { return output, errors.New("Invalid base64 UUID: \"" + input + "\"") }
+			copy(output[:], b)
+			return output, nil
+		},
+		func(u [16]byte) string { return base64.RawURLEncoding.EncodeToString(u[:]) },
+	},
+	{
+		"uuid58", "Short Base58 UUID",
+		func(input string) ([16]byte, error) {
+			var output [16]byte
+			b, err := decodeBase58(strings.TrimSpace(input), 16)
+			if err != nil { return output, err }
+			copy(output[:], b)
+			return output, nil
+		},
+		func(u [16]byte) string { return encodeBase58(u[:]) },
+	},
+	{
+		"uuidint", "UUID as a 128-bit Integer",
+		func(input string) ([16]byte, error) {
+			var output [16]byte
+			n, ok := new(big.Int).SetString(strings.TrimSpace(input), 10)
+			if !ok || n.Sign() < 0 || n.BitLen() > 128 { return output, errors.New("Invalid 128-bit integer: \"" + input + "\"") }
+			n.FillBytes(output[:])
+			return output, nil
+		},
+		func(u [16]byte) string { return new(big.Int).SetBytes(u[:]).String() },
+	},
+	{
+		"guidbytes", "Microsoft GUID Bytes (mixed-endian, in hexadecimal)",
+		func(input string) ([16]byte, error) {
+			u, err := parseUuidHex(input)
+			if err != nil { return u, err }
+			return swapGuidBytes(u), nil
+		},
+		func(u [16]byte) string {
+			b := swapGuidBytes(u)
+			return hex.EncodeToString(b[:])
+		},
+	},
+}
+
+func findUuidFormat(unit string) (uuidFormat, bool) {
+	unit = strings.ToLower(unit)
+	for _, f := range uuidFormats {
+		if f.unit == unit { return f, true }
+	}
+	return uuidFormat{}, false
+}
+
+// Generates version 4 (random) or version 7 (time-ordered) UUIDs. When the "seed" option
+// is set, the UUIDs are deterministic: the random bits come from the seed, and version 7
+// UUIDs use the Unix epoch as their time. Otherwise, they use the current time. The time
+// can also be given with the "uuid-time" option. Neither option is read from the saved
+// defaults, so that UUIDs can't be made predictable by mistake.
+func (this *Conversions) GenerateUuids(version int, count int) ([]string, error) {
+	if version != 4 && version != 7 { return nil, errors.New("Only version 4 and 7 UUIDs can be generated") }
+	if count < 1 { return nil, errors.New("Invalid count, expected at least 1 UUID: " + strconv.Itoa(count)) }
+
+	var random io.Reader = cryptorand.Reader
+	now := time.Now()
+	if seed := this.options_["seed"]; seed != "" {
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil { return nil, errors.New("Invalid seed, expected an integer: \"" + seed + "\"") }
+		random = rand.New(rand.NewSource(n))
+		now = time.Unix(0, 0)
+	}
+	if uuidTime := this.options_["uuid-time"]; uuidTime != "" {
+		var err error
+		now, err = parseWithLayouts(uuidTime, isoLayouts, time.UTC)
+		if err != nil { return nil, err }
+	}
+
+	var output []string
+	for i := 0; i < count; i++ {
+		var u [16]byte
+		_, err := io.ReadFull(random, u[:])
+		if err != nil { return nil, err }
+		if version == 7 {
+			var ms [8]byte
+			binary.BigEndian.PutUint64(ms[:], uint64(now.UnixMilli()))
+			copy(u[0:6], ms[2:8])
+		}
+		u[6] = u[6] & 0x0f | byte(version << 4)
+		u[8] = u[8] & 0x3f | 0x80
+		output = append(output, formatUuid(u))
+	}
+	return output, nil
+}
+
+func addUuidConversions(output *Conversions) {
+	for _, from := range uuidFormats {
+		for _, to := range uuidFormats {
+			from := from
+			to := to
+			output.Add(Conversion{
+				"id", from.unit, to.unit, func(input string) (string, error) {
+					u, err := from.parse(input)
+					if err != nil { return "", err }
+					return to.format(u), nil
+				},
+			})
+		}
+	}
+}
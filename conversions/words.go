@@ -0,0 +1,935 @@
+package conversions
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type numberLanguage struct {
+	code string
+	minus string
+	point string
+	// Word between the units and the subunits of an amount, eg. "and" in "two euros and ten cents"
+	and string
+	// Spells a non-negative number. A number before a noun, such as a currency, takes
+	// a different form in some languages, eg. "un euro" rather than "uno".
+	cardinal func(n int64, beforeNoun bool) string
+	ordinal func(n int64) (string, error)
+	// Turns an ordinal into the words of the cardinal number
+	ordinalToCardinal func(tokens []string, vocabulary map[string]int64) ([]string, error)
+	vocabulary func() map[string]int64
+}
+
+// Splits a number into groups of three digits, starting with the lowest
+func thousandGroups(n int64) []int64 {
+	var output []int64
+	for ; n > 0; n /= 1000 {
+		output = append(output, n % 1000)
+	}
+	return output
+}
+
+var englishOnes = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+	"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+var englishTens = []string{ "", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety" }
+var englishScales = []string{ "", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion" }
+var englishIrregularOrdinals = map[string]string{
+	"one": "first", "two": "second", "three": "third", "five": "fifth", "eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+}
+
+func englishBelow1000(n int64) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, englishOnes[n / 100] + " hundred")
+		n %= 100
+	}
+	if n >= 20 {
+		w := englishTens[n / 10]
+		if n % 10 > 0 { w += "-" + englishOnes[n % 10] }
+		words = append(words, w)
+	} else if n > 0 {
+		words = append(words, englishOnes[n])
+	}
+	return strings.Join(words, " ")
+}
+
+func englishCardinal(n int64, beforeNoun bool) string {
+	if n == 0 { return englishOnes[0] }
+	var words []string
+	for scale, group := range thousandGroups(n) {
+		if group == 0 { continue }
+		w := englishBelow1000(group)
+		if scale > 0 { w += " " + englishScales[scale] }
+		words = append([]string{ w }, words...)
+	}
+	return strings.Join(words, " ")
+}
+
+func englishOrdinal(n int64) (string, error) {
+	cardinal := englishCardinal(n, false)
+	i := strings.LastIndexAny(cardinal, " -")
+	last := cardinal[i + 1:]
+	if irregular, ok := englishIrregularOrdinals[last]; ok {
+		last = irregular
+	} else if strings.HasSuffix(last, "y") {
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	} else {
+		last += "th"
+	}
+	return cardinal[:i + 1] + last, nil
+}
+
+func englishOrdinalToCardinal(tokens []string, vocabulary map[string]int64) ([]string, error) {
+	last := tokens[len(tokens) - 1]
+	for cardinal, ordinal := range englishIrregularOrdinals {
+		if last == ordinal { last = cardinal }
+	}
+	if strings.HasSuffix(last, "ieth") {
+		last = strings.TrimSuffix(last, "ieth") + "y"
+	} else {
+		last = strings.TrimSuffix(last, "th")
+	}
+	return append(tokens[:len(tokens) - 1], last), nil
+}
+
+func englishVocabulary() map[string]int64 {
+	output := map[string]int64{ "hundred": 100 }
+	for i, w := range englishOnes {
+		output[w] = int64(i)
+	}
+	for i, w := range englishTens {
+		if w != "" { output[w] = int64(i * 10) }
+	}
+	scale := int64(1)
+	for _, w := range englishScales[1:] {
+		scale *= 1000
+		output[w] = scale
+	}
+	return output
+}
+
+var frenchOnes = []string{
+	"zéro", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf", "dix",
+	"onze", "douze", "treize", "quatorze", "quinze", "seize", "dix-sept", "dix-huit", "dix-neuf",
+}
+var frenchTens = []string{ "", "", "vingt", "trente", "quarante", "cinquante", "soixante" }
+var frenchScales = []string{ "", "mille", "million", "milliard", "billion", "billiard", "trillion" }
+
+// "Vingt" and "cent" take an "s" when they are multiplied and end the number, which
+// includes being followed by "million" as it is a noun, but not by "mille".
+func frenchBelow100(n int64, final bool) string {
+	if n < 20 { return frenchOnes[n] }
+	var base string
+	var rest int64
+	switch {
+		case n >= 80: base, rest = "quatre-vingt", n - 80
+		case n >= 60: base, rest = "soixante", n - 60
+		default: base, rest = frenchTens[n / 10], n % 10
+	}
+	switch {
+		case rest == 0 && n == 80 && final: return base + "s"
+		case rest == 0: return base
+		case rest == 1 && n < 80: return base + " et un"
+		case rest == 11 && n < 80: return base + " et onze"
+	}
+	return base + "-" + frenchOnes[rest]
+}
+
+func frenchBelow1000(n int64, final bool) string {
+	var words []string
+	hundreds, rest := n / 100, n % 100
+	if hundreds == 1 {
+		words = append(words, "cent")
+	} else if hundreds > 1 {
+		w := frenchOnes[hundreds] + " cent"
+		if rest == 0 && final { w += "s" }
+		words = append(words, w)
+	}
+	if rest > 0 { words = append(words, frenchBelow100(rest, final)) }
+	return strings.Join(words, " ")
+}
+
+func frenchCardinal(n int64, beforeNoun bool) string {
+	if n == 0 { return frenchOnes[0] }
+	var words []string
+	for scale, group := range thousandGroups(n) {
+		var w string
+		switch {
+			case group == 0: continue
+			case scale == 0: w = frenchBelow1000(group, true)
+			case scale == 1 && group == 1: w = "mille"
+			case scale == 1: w = frenchBelow1000(group, false) + " mille"
+			default:
+				w = frenchBelow1000(group, true) + " " + frenchScales[scale]
+				if group > 1 { w += "s" }
+		}
+		words = append([]string{ w }, words...)
+	}
+	return strings.Join(words, " ")
+}
+
+func frenchOrdinal(n int64) (string, error) {
+	if n == 1 { return "premier", nil }
+	cardinal := frenchCardinal(n, false)
+	// "Un million" is "millionième"
+	if strings.HasPrefix(cardinal, "un ") && !strings.Contains(cardinal[3:], " ") { cardinal = cardinal[3:] }
+	i := strings.LastIndexAny(cardinal, " -")
+	last := cardinal[i + 1:]
+	switch last {
+		case "cinq": last = "cinqu"
+		case "neuf": last = "neuv"
+		case "trois":
+		default:
+			last = strings.TrimSuffix(strings.TrimSuffix(last, "s"), "e")
+	}
+	return cardinal[:i + 1] + last + "ième", nil
+}
+
+func frenchOrdinalToCardinal(tokens []string, vocabulary map[string]int64) ([]string, error) {
+	last := tokens[len(tokens) - 1]
+	switch {
+		case last == "premier" || last == "premiere": last = "un"
+		case last == "second" || last == "seconde": last = "deux"
+		case strings.HasSuffix(last, "ieme"):
+			stem := strings.TrimSuffix(last, "ieme")
+			switch {
+				case stem == "cinqu": last = "cinq"
+				case stem == "neuv": last = "neuf"
+				case vocabulary[stem + "e"] > 0: last = stem + "e"
+				default: last = stem
+			}
+		default:
+			return nil, errors.New("Not a French ordinal: \"" + last + "\"")
+	}
+	return append(tokens[:len(tokens) - 1], last), nil
+}
+
+func frenchVocabulary() map[string]int64 {
+	output := map[string]int64{ "une": 1, "vingts": 20, "cent": 100, "cents": 100, "mil": 1000 }
+	for i, w := range frenchOnes {
+		// The compounds such as "dix-sept" are parsed as their parts
+		if !strings.Contains(w, "-") { output[w] = int64(i) }
+	}
+	for i, w := range frenchTens {
+		if w != "" { output[w] = int64(i * 10) }
+	}
+	scale := int64(1)
+	for _, w := range frenchScales[1:] {
+		scale *= 1000
+		output[w] = scale
+		output[w + "s"] = scale
+	}
+	return output
+}
+
+var germanOnes = []string{
+	"null", "eins", "zwei", "drei", "vier", "fünf", "sechs", "sieben", "acht", "neun", "zehn",
+	"elf", "zwölf", "dreizehn", "vierzehn", "fünfzehn", "sechzehn", "siebzehn", "achtzehn", "neunzehn",
+}
+var germanTens = []string{ "", "", "zwanzig", "dreißig", "vierzig", "fünfzig", "sechzig", "siebzig", "achtzig", "neunzig" }
+var germanScales = [][2]string{
+	{ "Million", "Millionen" }, { "Milliarde", "Milliarden" }, { "Billion", "Billionen" }, { "Billiarde", "Billiarden" }, { "Trillion", "Trillionen" },
+}
+
+// Spells a number below 1000 as a single word. The final "one" is "eins" at the end of
+// a number and "ein" before a noun.
+func germanBelow1000(n int64, one string) string {
+	output := ""
+	if n >= 100 {
+		if n / 100 == 1 {
+			output += "ein"
+		} else {
+			output += germanOnes[n / 100]
+		}
+		output += "hundert"
+		n %= 100
+	}
+	switch {
+		case n == 1: output += one
+		case n > 0 && n < 20: output += germanOnes[n]
+		case n >= 20:
+			if n % 10 == 1 {
+				output += "einund"
+			} else if n % 10 > 0 {
+				output += germanOnes[n % 10] + "und"
+			}
+			output += germanTens[n / 10]
+	}
+	return output
+}
+
+// Spells a number in German. Numbers below a million are written as a single word,
+// and the millions and above as separate nouns, eg. "zwei Millionen dreihunderttausend".
+func germanCardinal(n int64, beforeNoun bool) string {
+	if n == 0 { return germanOnes[0] }
+	one := "eins"
+	if beforeNoun { one = "ein" }
+	var words []string
+	for scale, group := range thousandGroups(n / 1000000) {
+		if group == 0 { continue }
+		w := "eine " + germanScales[scale][0]
+		if group > 1 { w = germanBelow1000(group, "ein") + " " + germanScales[scale][1] }
+		words = append([]string{ w }, words...)
+	}
+	if below := n % 1000000; below > 0 {
+		w := ""
+		if below >= 1000 { w = germanBelow1000(below / 1000, "ein") + "tausend" }
+		words = append(words, w + germanBelow1000(below % 1000, one))
+	}
+	return strings.Join(words, " ")
+}
+
+func germanOrdinal(n int64) (string, error) {
+	if n == 0 { return "nullte", nil }
+	words := strings.Split(germanCardinal(n, false), " ")
+	last := words[len(words) - 1]
+	for _, scale := range germanScales {
+		if last == scale[0] || last == scale[1] {
+			// "Zwei Millionen" is "zweimillionste" and "eine Million" is "millionste"
+			prefix := words[len(words) - 2]
+			if prefix == "eine" { prefix = "" }
+			words = append(words[:len(words) - 2], prefix + strings.TrimSuffix(strings.ToLower(scale[0]), "e") + "ste")
+			return strings.Join(words, " "), nil
+		}
+	}
+	switch {
+		case strings.HasSuffix(last, "eins"): last = strings.TrimSuffix(last, "eins") + "erste"
+		case strings.HasSuffix(last, "drei"): last = strings.TrimSuffix(last, "drei") + "dritte"
+		case strings.HasSuffix(last, "sieben"): last = strings.TrimSuffix(last, "sieben") + "siebte"
+		case strings.HasSuffix(last, "acht"): last += "e"
+		case n % 100 > 0 && n % 100 < 20: last += "te"
+		default: last += "ste"
+	}
+	words[len(words) - 1] = last
+	return strings.Join(words, " "), nil
+}
+
+func germanOrdinalToCardinal(tokens []string, vocabulary map[string]int64) ([]string, error) {
+	last := tokens[len(tokens) - 1]
+	// Ordinals are declined like adjectives, eg. "der erste" and "am ersten"
+	if len(last) > 3 && strings.HasSuffix(last[:len(last) - 1], "te") && strings.IndexByte("rnsm", last[len(last) - 1]) >= 0 {
+		last = last[:len(last) - 1]
+	}
+	irregular := []struct { ordinal string; cardinal string }{ { "erste", "eins" }, { "dritte", "drei" }, { "siebte", "sieben" }, { "achte", "acht" } }
+	for _, i := range irregular {
+		if strings.HasSuffix(last, i.ordinal) { return append(tokens[:len(tokens) - 1], strings.TrimSuffix(last, i.ordinal) + i.cardinal), nil }
+	}
+	for _, suffix := range []string{ "ste", "te" } {
+		stem := strings.TrimSuffix(last, suffix)
+		if stem == last { continue }
+		if _, err := segmentNumberWord(stem, vocabulary); err == nil {
+			return append(tokens[:len(tokens) - 1], stem), nil
+		}
+	}
+	return nil, errors.New("Not a German ordinal: \"" + last + "\"")
+}
+
+func germanVocabulary() map[string]int64 {
+	output := map[string]int64{ "ein": 1, "eine": 1, "hundert": 100, "tausend": 1000 }
+	for i, w := range germanOnes {
+		output[w] = int64(i)
+	}
+	for i, w := range germanTens {
+		if w != "" { output[w] = int64(i * 10) }
+	}
+	scale := int64(1000)
+	for _, w := range germanScales {
+		scale *= 1000
+		output[w[0]] = scale
+		output[w[1]] = scale
+	}
+	return output
+}
+
+var spanishOnes = []string{
+	"cero", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve", "diez",
+	"once", "doce", "trece", "catorce", "quince", "dieciséis", "diecisiete", "dieciocho", "diecinueve",
+	"veinte", "veintiuno", "veintidós", "veintitrés", "veinticuatro", "veinticinco", "veintiséis", "veintisiete", "veintiocho", "veintinueve",
+}
+var spanishTens = []string{ "", "", "", "treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta", "noventa" }
+var spanishHundreds = []string{
+	"", "ciento", "doscientos", "trescientos", "cuatrocientos", "quinientos", "seiscientos", "setecientos", "ochocientos", "novecientos",
+}
+// Spanish uses the long scale, so a "billón" is a million millions
+var spanishScales = [][2]string{ { "millón", "millones" }, { "billón", "billones" }, { "trillón", "trillones" } }
+
+var spanishOrdinalUnits = []string{ "", "primero", "segundo", "tercero", "cuarto", "quinto", "sexto", "séptimo", "octavo", "noveno" }
+var spanishOrdinalTeens = []string{
+	"décimo", "undécimo", "duodécimo", "decimotercero", "decimocuarto", "decimoquinto", "decimosexto", "decimoséptimo", "decimoctavo", "decimonoveno",
+}
+var spanishOrdinalTens = []string{
+	"", "décimo", "vigésimo", "trigésimo", "cuadragésimo", "quincuagésimo", "sexagésimo", "septuagésimo", "octogésimo", "nonagésimo",
+}
+var spanishOrdinalHundreds = []string{
+	"", "centésimo", "ducentésimo", "tricentésimo", "cuadringentésimo", "quingentésimo", "sexcentésimo", "septingentésimo", "octingentésimo", "noningentésimo",
+}
+
+// Spells a number below 1000. Before a noun, "uno" is shortened to "un", eg. "veintiún euros".
+func spanishBelow1000(n int64, beforeNoun bool) string {
+	hundreds, rest := n / 100, n % 100
+	if hundreds == 1 && rest == 0 { return "cien" }
+	var words []string
+	if hundreds > 0 { words = append(words, spanishHundreds[hundreds]) }
+	if rest > 0 {
+		var w string
+		if rest < 30 {
+			w = spanishOnes[rest]
+		} else {
+			w = spanishTens[rest / 10]
+			if rest % 10 > 0 { w += " y " + spanishOnes[rest % 10] }
+		}
+		if beforeNoun && strings.HasSuffix(w, "uno") {
+			w = strings.TrimSuffix(w, "uno") + "un"
+			if w == "veintiun" { w = "veintiún" }
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+func spanishBelowMillion(n int64, beforeNoun bool) string {
+	var words []string
+	thousands, rest := n / 1000, n % 1000
+	if thousands == 1 {
+		words = append(words, "mil")
+	} else if thousands > 1 {
+		words = append(words, spanishBelow1000(thousands, true) + " mil")
+	}
+	if rest > 0 { words = append(words, spanishBelow1000(rest, beforeNoun)) }
+	return strings.Join(words, " ")
+}
+
+func spanishCardinal(n int64, beforeNoun bool) string {
+	if n == 0 { return spanishOnes[0] }
+	var words []string
+	scale := 0
+	for millions := n / 1000000; millions > 0; millions /= 1000000 {
+		if group := millions % 1000000; group == 1 {
+			words = append([]string{ "un " + spanishScales[scale][0] }, words...)
+		} else if group > 1 {
+			words = append([]string{ spanishBelowMillion(group, true) + " " + spanishScales[scale][1] }, words...)
+		}
+		scale++
+	}
+	if below := n % 1000000; below > 0 { words = append(words, spanishBelowMillion(below, beforeNoun)) }
+	return strings.Join(words, " ")
+}
+
+func spanishOrdinal(n int64) (string, error) {
+	if n <= 0 || n >= 1000000 { return "", errors.New("Spanish ordinals are supported from 1 to 999999") }
+	var words []string
+	if thousands := n / 1000; thousands == 1 {
+		words = append(words, "milésimo")
+	} else if thousands > 1 {
+		// The multiplier is joined to the ordinal, eg. "dosmilésimo"
+		words = append(words, strings.Replace(spanishBelow1000(thousands, true), " ", "", -1) + "milésimo")
+	}
+	rest := n % 1000
+	words = append(words, spanishOrdinalHundreds[rest / 100])
+	if rest % 100 >= 10 && rest % 100 < 20 {
+		words = append(words, spanishOrdinalTeens[rest % 10])
+	} else {
+		words = append(words, spanishOrdinalTens[rest % 100 / 10], spanishOrdinalUnits[rest % 10])
+	}
+	var output []string
+	for _, w := range words {
+		if w != "" { output = append(output, w) }
+	}
+	return strings.Join(output, " "), nil
+}
+
+// Spanish ordinals are made of words that each have a value, so they are summed and
+// returned as digits
+func spanishOrdinalToCardinal(tokens []string, vocabulary map[string]int64) ([]string, error) {
+	ordinals := make(map[string]int64)
+	for i, w := range spanishOrdinalUnits {
+		ordinals[foldToAscii(w)] = int64(i)
+	}
+	for i, w := range spanishOrdinalTeens {
+		ordinals[foldToAscii(w)] = int64(10 + i)
+	}
+	for i, w := range spanishOrdinalTens {
+		ordinals[foldToAscii(w)] = int64(i * 10)
+	}
+	for i, w := range spanishOrdinalHundreds {
+		ordinals[foldToAscii(w)] = int64(i * 100)
+	}
+	total := int64(0)
+	for _, token := range tokens {
+		// Feminine and shortened forms, eg. "primera" and "tercer"
+		if strings.HasSuffix(token, "a") { token = strings.TrimSuffix(token, "a") + "o" }
+		if token == "primer" || token == "tercer" { token += "o" }
+		if value, ok := ordinals[token]; ok && token != "" {
+			total += value
+			continue
+		}
+		if strings.HasSuffix(token, "milesimo") {
+			multiplier := int64(1)
+			if prefix := strings.TrimSuffix(token, "milesimo"); prefix != "" {
+				words, err := segmentNumberWord(prefix, vocabulary)
+				if err != nil { return nil, err }
+				multiplier, err = parseCardinalWords(words, vocabulary, "es")
+				if err != nil { return nil, err }
+			}
+			thousands, err := multiplyWordValues(multiplier, 1000)
+			if err != nil { return nil, err }
+			total, err = addWordValues(total, thousands)
+			if err != nil { return nil, err }
+			continue
+		}
+		return nil, errors.New("Not a Spanish ordinal: \"" + token + "\"")
+	}
+	return []string{ strconv.FormatInt(total, 10) }, nil
+}
+
+func spanishVocabulary() map[string]int64 {
+	output := map[string]int64{ "un": 1, "una": 1, "veintiun": 21, "veintiuna": 21, "cien": 100, "mil": 1000 }
+	for i, w := range spanishOnes {
+		output[w] = int64(i)
+	}
+	for i, w := range spanishTens {
+		if w != "" { output[w] = int64(i * 10) }
+	}
+	for i, w := range spanishHundreds {
+		if w == "" { continue }
+		output[w] = int64(i * 100)
+		output[strings.TrimSuffix(w, "os") + "as"] = int64(i * 100)
+	}
+	scale := int64(1)
+	for _, w := range spanishScales {
+		scale *= 1000000
+		output[w[0]] = scale
+		output[w[1]] = scale
+	}
+	return output
+}
+
+var numberLanguages = []numberLanguage{
+	{
+		"en", "minus", "point", "and",
+		englishCardinal, englishOrdinal, englishOrdinalToCardinal, englishVocabulary,
+	},
+	{
+		"fr", "moins", "virgule", "et",
+		frenchCardinal, frenchOrdinal, frenchOrdinalToCardinal, frenchVocabulary,
+	},
+	{
+		"de", "minus", "Komma", "und",
+		germanCardinal, germanOrdinal, germanOrdinalToCardinal, germanVocabulary,
+	},
+	{
+		"es", "menos", "coma", "con",
+		spanishCardinal, spanishOrdinal, spanishOrdinalToCardinal, spanishVocabulary,
+	},
+}
+
+func (this *Conversions) numberLanguage() (numberLanguage, error) {
+	code := strings.ToLower(this.option("lang", "en"))
+	for _, l := range numberLanguages {
+		if l.code == code { return l, nil }
+	}
+	return numberLanguage{}, errors.New("Unsupported language: \"" + code + "\", the languages are en, fr, de and es")
+}
+
+type currencyWords struct {
+	singular string
+	plural string
+	subunitSingular string
+	subunitPlural string
+}
+
+// Names of the most common currencies. In English, the others are named after the
+// currency list, with cents.
+var currencyWordsByLanguage = map[string]map[string]currencyWords{
+	"en": {
+		"EUR": { "euro", "euros", "cent", "cents" },
+		"USD": { "dollar", "dollars", "cent", "cents" },
+		"GBP": { "pound", "pounds", "penny", "pence" },
+		"CHF": { "Swiss franc", "Swiss francs", "centime", "centimes" },
+		"JPY": { "yen", "yen", "", "" },
+		"CAD": { "Canadian dollar", "Canadian dollars", "cent", "cents" },
+		"AUD": { "Australian dollar", "Australian dollars", "cent", "cents" },
+	},
+	"fr": {
+		"EUR": { "euro", "euros", "centime", "centimes" },
+		"USD": { "dollar", "dollars", "cent", "cents" },
+		"GBP": { "livre sterling", "livres sterling", "penny", "pence" },
+		"CHF": { "franc suisse", "francs suisses", "centime", "centimes" },
+		"JPY": { "yen", "yens", "", "" },
+		"CAD": { "dollar canadien", "dollars canadiens", "cent", "cents" },
+		"AUD": { "dollar australien", "dollars australiens", "cent", "cents" },
+	},
+	"de": {
+		"EUR": { "Euro", "Euro", "Cent", "Cent" },
+		"USD": { "US-Dollar", "US-Dollar", "Cent", "Cent" },
+		"GBP": { "Pfund", "Pfund", "Penny", "Pence" },
+		"CHF": { "Franken", "Franken", "Rappen", "Rappen" },
+		"JPY": { "Yen", "Yen", "", "" },
+		"CAD": { "kanadischer Dollar", "kanadische Dollar", "Cent", "Cent" },
+		"AUD": { "australischer Dollar", "australische Dollar", "Cent", "Cent" },
+	},
+	"es": {
+		"EUR": { "euro", "euros", "céntimo", "céntimos" },
+		"USD": { "dólar", "dólares", "centavo", "centavos" },
+		"GBP": { "libra", "libras", "penique", "peniques" },
+		"CHF": { "franco suizo", "francos suizos", "céntimo", "céntimos" },
+		"JPY": { "yen", "yenes", "", "" },
+		"CAD": { "dólar canadiense", "dólares canadienses", "centavo", "centavos" },
+		"AUD": { "dólar australiano", "dólares australianos", "centavo", "centavos" },
+	},
+}
+
+// Currencies whose name is a feminine noun, which the number before it agrees with
+var feminineCurrencyNames = map[string]bool{ "livre sterling": true, "libra": true }
+
+// Turns the number before a feminine noun to the feminine, eg. "veintiuna libras". In
+// Spanish, the hundreds agree too, unless they count millions.
+func feminineNumber(lang numberLanguage, number string) string {
+	words := strings.Split(number, " ")
+	for i := len(words) - 1; i >= 0; i-- {
+		w := words[i]
+		if lang.code == "fr" && i == len(words) - 1 && (w == "un" || strings.HasSuffix(w, "-un")) { words[i] = w + "e" }
+		if lang.code != "es" { continue }
+		if strings.HasPrefix(w, "millón") || strings.HasPrefix(w, "millon") || strings.HasPrefix(w, "billón") || strings.HasPrefix(w, "billon") || strings.HasPrefix(w, "trillón") || strings.HasPrefix(w, "trillon") { break }
+		switch {
+			case w == "un": words[i] = "una"
+			case w == "veintiún": words[i] = "veintiuna"
+			case strings.HasSuffix(w, "ientos"): words[i] = strings.TrimSuffix(w, "os") + "as"
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Returns the words of a currency. In languages other than English, the names of the
+// currency list can't be used, so only the currencies of the table are supported.
+func (this *Conversions) currencyWords(lang numberLanguage, code string) (currencyWords, error) {
+	if words, ok := currencyWordsByLanguage[lang.code][strings.ToUpper(code)]; ok { return words, nil }
+	if lang.code != "en" { return currencyWords{}, errors.New("Unsupported currency for --lang " + lang.code + ": " + strings.ToUpper(code)) }
+	name := this.NiceUnitName("currency", code)
+	return currencyWords{ name, name + "s", "cent", "cents" }, nil
+}
+
+// Parses a decimal number, keeping the digits of the fraction as they are written
+func parseDecimalNumber(input string) (bool, int64, string, error) {
+	s := strings.Replace(strings.TrimSpace(input), "_", "", -1)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	parts := strings.SplitN(s, ".", 2)
+	fraction := ""
+	if len(parts) == 2 {
+		fraction = parts[1]
+		if parts[0] == "" { parts[0] = "0" }
+	}
+	whole, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || whole < 0 || strings.Trim(fraction, "0123456789") != "" || (len(parts) == 2 && fraction == "") {
+		return false, 0, "", errors.New("Invalid number: \"" + input + "\"")
+	}
+	return negative, whole, fraction, nil
+}
+
+func (this *Conversions) numberToWords(input string) (string, error) {
+	lang, err := this.numberLanguage()
+	if err != nil { return "", err }
+	negative, whole, fraction, err := parseDecimalNumber(input)
+	if err != nil { return "", err }
+	words := []string{ lang.cardinal(whole, false) }
+	if negative { words = append([]string{ lang.minus }, words...) }
+	if fraction != "" {
+		// The decimals are read digit by digit
+		words = append(words, lang.point)
+		for _, digit := range fraction {
+			words = append(words, lang.cardinal(int64(digit - '0'), false))
+		}
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (this *Conversions) numberToOrdinal(input string) (string, error) {
+	lang, err := this.numberLanguage()
+	if err != nil { return "", err }
+	n, err := strconv.ParseInt(strings.TrimSpace(input), 10, 64)
+	if err != nil || n < 0 { return "", errors.New("Ordinals are made of whole positive numbers: \"" + input + "\"") }
+	return lang.ordinal(n)
+}
+
+// Writes an amount in words, as on cheques, eg. "one thousand euros and fifty cents".
+// The amount is rounded to the subunit, or to units for currencies that don't have one.
+func (this *Conversions) currencyToWords(code string, input string) (string, error) {
+	lang, err := this.numberLanguage()
+	if err != nil { return "", err }
+	negative, whole, fraction, err := parseDecimalNumber(input)
+	if err != nil { return "", err }
+	names, err := this.currencyWords(lang, code)
+	if err != nil { return "", err }
+
+	fraction += "000"
+	subunits, _ := strconv.ParseInt(fraction[0:2], 10, 64)
+	if fraction[2] >= '5' { subunits++ }
+	if names.subunitSingular == "" {
+		if fraction[0] >= '5' { whole++ }
+		subunits = 0
+	}
+	if subunits == 100 {
+		whole++
+		subunits = 0
+	}
+
+	unit := names.plural
+	if whole == 1 || (whole == 0 && lang.code == "fr") { unit = names.singular }
+	number := lang.cardinal(whole, true)
+	if feminineCurrencyNames[names.singular] { number = feminineNumber(lang, number) }
+	output := number + " " + unit
+	// French and Spanish say "un million d'euros" and "un millón de euros"
+	if whole >= 1000000 && whole % 1000000 == 0 && (lang.code == "fr" || lang.code == "es") {
+		of := "de "
+		if lang.code == "fr" && strings.ContainsRune("aeiouy", []rune(foldToAscii(unit))[0]) { of = "d'" }
+		output = number + " " + of + unit
+	}
+	if subunits > 0 {
+		subunit := names.subunitPlural
+		if subunits == 1 { subunit = names.subunitSingular }
+		output += " " + lang.and + " " + lang.cardinal(subunits, true) + " " + subunit
+	}
+	if negative { output = lang.minus + " " + output }
+	return output, nil
+}
+
+// Splits the words of a number, which are written without spaces in German, with the
+// longest words of the vocabulary first
+func segmentNumberWord(word string, vocabulary map[string]int64) ([]string, error) {
+	keys := []string{ "und", "y" }
+	for k := range vocabulary {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	var output []string
+	for rest := word; rest != ""; {
+		found := false
+		for _, k := range keys {
+			if strings.HasPrefix(rest, k) {
+				output = append(output, k)
+				rest = rest[len(k):]
+				found = true
+				break
+			}
+		}
+		if !found { return nil, errors.New("Unknown number word: \"" + rest + "\"") }
+	}
+	return output, nil
+}
+
+func numberWordTokens(lang numberLanguage, input string, vocabulary map[string]int64) ([]string, error) {
+	s := foldToAscii(strings.ToLower(strings.TrimSpace(input)))
+	s = strings.Replace(s, "-", " ", -1)
+	var output []string
+	for _, token := range strings.Fields(s) {
+		if lang.code != "de" || token == foldToAscii(strings.ToLower(lang.point)) {
+			output = append(output, token)
+			continue
+		}
+		words, err := segmentNumberWord(token, vocabulary)
+		if err != nil {
+			// Ordinals are split after their suffix has been removed
+			output = append(output, token)
+			continue
+		}
+		output = append(output, words...)
+	}
+	if len(output) == 0 { return nil, errors.New("No number to parse") }
+	return output, nil
+}
+
+// Checks that a number below 100, or a Spanish hundred, can follow the words of the group
+// of three digits that has been read so far, eg. that "one" can follow "twenty" but not
+// "ten" or "one".
+func validNumberWordOrder(current int64, value int64, langCode string) bool {
+	last := current % 100
+	switch {
+		case value >= 100:
+			return current == 0
+		// "Vingt" after "quatre" is handled as "quatre-vingts"
+		case value >= 20 && value % 10 == 0:
+			// German puts the units first, eg. "einundzwanzig"
+			return last == 0 || (langCode == "de" && last < 10)
+		case value >= 10:
+			// French counts 70 and 90 as "soixante-dix" and "quatre-vingt-dix"
+			return last == 0 || (langCode == "fr" && value < 20 && (last == 60 || last == 80))
+		default:
+			// French says "dix-sept" for 17
+			return (last % 10 == 0 && (last < 10 || last >= 20)) || (langCode == "fr" && last % 100 == 10 && value >= 7)
+	}
+}
+
+var errNumberTooLarge = errors.New("Number is too large")
+
+// Adds or multiplies the values of number words, which are positive, failing when the result
+// doesn't fit in an int64
+func addWordValues(a int64, b int64) (int64, error) {
+	sum, carry := bits.Add64(uint64(a), uint64(b), 0)
+	if carry != 0 || sum > math.MaxInt64 { return 0, errNumberTooLarge }
+	return int64(sum), nil
+}
+
+func multiplyWordValues(a int64, b int64) (int64, error) {
+	high, low := bits.Mul64(uint64(a), uint64(b))
+	if high != 0 || low > math.MaxInt64 { return 0, errNumberTooLarge }
+	return int64(low), nil
+}
+
+// Parses the words of a whole number. Each scale word multiplies what comes before it
+// down to the previous larger scale, so that "mil millones" is a thousand millions.
+// Words in an order that doesn't make a number, such as "one one" or "thousand
+// thousand", are rejected.
+func parseCardinalWords(tokens []string, vocabulary map[string]int64, langCode string) (int64, error) {
+	type part struct {
+		value int64
+		scale int64
+	}
+	var parts []part
+	current := int64(0)
+	found := false
+	for _, token := range tokens {
+		// The words that join numbers, eg. "und" in "einundzwanzig"
+		if token == "and" || token == "et" || token == "und" || token == "y" { continue }
+		found = true
+		invalid := errors.New("Invalid number: \"" + strings.Join(tokens, " ") + "\", unexpected \"" + token + "\"")
+		value, ok := vocabulary[token]
+		if !ok {
+			// Numbers given as digits, eg. Spanish ordinals
+			n, err := strconv.ParseInt(token, 10, 64)
+			if err != nil { return 0, errors.New("Unknown number word: \"" + token + "\"") }
+			if current != 0 { return 0, invalid }
+			current = n
+			continue
+		}
+		switch {
+			case value >= 1000:
+				amount := current
+				// A larger scale multiplies the smaller one right before it, eg. "mil millones".
+				// In Spanish, it also multiplies the thousands and the units after them, eg.
+				// "dos mil tres millones" is 2003 millions.
+				if len(parts) > 0 && parts[len(parts) - 1].scale < value {
+					if current != 0 && !(langCode == "es" && parts[len(parts) - 1].scale == 1000) { return 0, invalid }
+					for len(parts) > 0 && parts[len(parts) - 1].scale < value {
+						var err error
+						amount, err = addWordValues(amount, parts[len(parts) - 1].value)
+						if err != nil { return 0, err }
+						parts = parts[:len(parts) - 1]
+					}
+				}
+				if len(parts) > 0 && parts[len(parts) - 1].scale <= value { return 0, invalid }
+				if amount == 0 { amount = 1 }
+				amount, err := multiplyWordValues(amount, value)
+				if err != nil { return 0, err }
+				parts = append(parts, part{ amount, value })
+				current = 0
+			case value == 100 && langCode != "es":
+				if current >= 100 { return 0, invalid }
+				if current == 0 { current = 1 }
+				current *= 100
+			// "Quatre-vingts" is four twenties
+			case value == 20 && langCode == "fr" && current % 100 == 4:
+				current += 76
+			default:
+				if !validNumberWordOrder(current, value, langCode) { return 0, invalid }
+				current += value
+		}
+	}
+	if !found { return 0, errors.New("No number to parse") }
+	for _, p := range parts {
+		var err error
+		current, err = addWordValues(current, p.value)
+		if err != nil { return 0, err }
+	}
+	return current, nil
+}
+
+func parseNumberWords(lang numberLanguage, input string, ordinal bool) (string, error) {
+	vocabulary := make(map[string]int64)
+	for k, v := range lang.vocabulary() {
+		vocabulary[foldToAscii(strings.ToLower(k))] = v
+	}
+	tokens, err := numberWordTokens(lang, input, vocabulary)
+	if err != nil { return "", err }
+	sign := ""
+	if tokens[0] == foldToAscii(lang.minus) {
+		sign = "-"
+		tokens = tokens[1:]
+	}
+	fractionTokens := []string{}
+	for i, token := range tokens {
+		if token == foldToAscii(strings.ToLower(lang.point)) {
+			fractionTokens = tokens[i + 1:]
+			tokens = tokens[:i]
+			if len(fractionTokens) == 0 { return "", errors.New("No decimals after \"" + lang.point + "\"") }
+			break
+		}
+	}
+	if len(tokens) == 0 { return "", errors.New("No number to parse") }
+	if ordinal {
+		tokens, err = lang.ordinalToCardinal(tokens, vocabulary)
+		if err != nil { return "", err }
+		if lang.code == "de" {
+			tokens, err = numberWordTokens(lang, strings.Join(tokens, " "), vocabulary)
+			if err != nil { return "", err }
+		}
+	}
+	whole, err := parseCardinalWords(tokens, vocabulary, lang.code)
+	if err != nil { return "", err }
+	output := sign + strconv.FormatInt(whole, 10)
+	if len(fractionTokens) > 0 {
+		// Decimals are read either digit by digit or as a number
+		digits := ""
+		for _, token := range fractionTokens {
+			value, ok := vocabulary[token]
+			if !ok || value > 9 {
+				digits = ""
+				break
+			}
+			digits += strconv.FormatInt(value, 10)
+		}
+		if digits == "" {
+			n, err := parseCardinalWords(fractionTokens, vocabulary, lang.code)
+			if err != nil { return "", err }
+			digits = strconv.FormatInt(n, 10)
+		}
+		output += "." + digits
+	}
+	return output, nil
+}
+
+func addWordsConversions(output *Conversions) {
+	output.Add(Conversion{ "words", "dec", "words", output.numberToWords })
+	output.Add(Conversion{ "words", "dec", "ordinal", output.numberToOrdinal })
+
+	output.Add(Conversion{
+		"words", "words", "dec", func(input string) (string, error) {
+			lang, err := output.numberLanguage()
+			if err != nil { return "", err }
+			return parseNumberWords(lang, input, false)
+		},
+	})
+
+	output.Add(Conversion{
+		"words", "ordinal", "dec", func(input string) (string, error) {
+			lang, err := output.numberLanguage()
+			if err != nil { return "", err }
+			return parseNumberWords(lang, input, true)
+		},
+	})
+
+	for _, row := range output.currencies {
+		code := row[0]
+		output.Add(Conversion{
+			"words", code, "words", func(input string) (string, error) {
+				return output.currencyToWords(code, input)
+			},
+		})
+	}
+}
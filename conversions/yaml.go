@@ -0,0 +1,615 @@
+package conversions
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Reads the block and flow styles of YAML, with comments, quoted scalars and literal and
+// folded block scalars. Anchors, aliases, tags and documents with several values aren't
+// supported.
+type yamlParser struct {
+	lines []string
+	pos int
+}
+
+func (this *yamlParser) errorf(message string) error {
+	return this.errorAt(this.pos, message)
+}
+
+func (this *yamlParser) errorAt(pos int, message string) error {
+	return errors.New("Invalid YAML at line " + strconv.Itoa(pos + 1) + ": " + message)
+}
+
+func (this *yamlParser) eof() bool {
+	return this.pos >= len(this.lines)
+}
+
+func (this *yamlParser) indent() int {
+	line := this.lines[this.pos]
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func (this *yamlParser) text() string {
+	return strings.TrimRight(this.lines[this.pos][this.indent():], " \t\r")
+}
+
+// Skips empty lines and comments. The indentation of the next line is checked before it's
+// compared with the one of other lines, as it can't have tabs.
+func (this *yamlParser) skipBlank() error {
+	for !this.eof() {
+		text := strings.TrimSpace(this.lines[this.pos])
+		if text != "" && !strings.HasPrefix(text, "#") {
+			if strings.HasPrefix(strings.TrimLeft(this.lines[this.pos], " "), "\t") { return this.errorf("tabs can't be used for indentation") }
+			return nil
+		}
+		this.pos++
+	}
+	return nil
+}
+
+func isYamlSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Whether a line ends the document ("...") or starts another one ("---")
+func (this *yamlParser) atDocumentMarker() bool {
+	text := this.text()
+	return this.indent() == 0 && (text == "..." || text == "---" || strings.HasPrefix(text, "--- "))
+}
+
+func (this *yamlParser) parse() (interface{}, error) {
+	if err := this.skipBlank(); err != nil { return nil, err }
+	for !this.eof() && strings.HasPrefix(this.lines[this.pos], "%") {
+		this.pos++
+		if err := this.skipBlank(); err != nil { return nil, err }
+	}
+	if !this.eof() && (this.text() == "---" || strings.HasPrefix(this.text(), "--- ")) {
+		this.lines[this.pos] = strings.TrimPrefix(this.text(), "---")
+	}
+	output, err := this.parseNode(0)
+	if err != nil { return nil, err }
+	if err := this.skipBlank(); err != nil { return nil, err }
+	if !this.eof() {
+		switch text := this.text(); {
+			case text == "...":
+			case text == "---" || strings.HasPrefix(text, "--- "):
+				return nil, this.errorf("several documents aren't supported")
+			default:
+				return nil, this.errorf("unexpected indentation")
+		}
+	}
+	return output, nil
+}
+
+// Parses the node that starts on the next line, if it's indented by at least minIndent
+func (this *yamlParser) parseNode(minIndent int) (interface{}, error) {
+	if err := this.skipBlank(); err != nil { return nil, err }
+	if this.eof() || this.indent() < minIndent || this.atDocumentMarker() { return nil, nil }
+	text := this.text()
+	if isYamlSequenceItem(text) { return this.parseSequence(this.indent()) }
+	if _, _, ok := splitYamlKey(text); ok { return this.parseMapping(this.indent()) }
+	this.pos++
+	return this.parseValue(strings.TrimSpace(text), minIndent - 1)
+}
+
+func (this *yamlParser) parseSequence(indent int) (interface{}, error) {
+	output := []interface{}{}
+	for {
+		if err := this.skipBlank(); err != nil { return nil, err }
+		if this.eof() || this.indent() != indent || !isYamlSequenceItem(this.text()) { return output, nil }
+		text := this.text()
+		rest := strings.TrimLeft(text[1:], " ")
+		var item interface{}
+		var err error
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			this.pos++
+			item, err = this.parseNode(indent + 1)
+		} else if _, _, ok := splitYamlKey(rest); ok || isYamlSequenceItem(rest) {
+			// A nested block starts on the same line, so the line is parsed again as
+			// if it only contained the block, at the column where it starts
+			column := indent + len(text) - len(rest)
+			this.lines[this.pos] = strings.Repeat(" ", column) + rest
+			item, err = this.parseNode(column)
+		} else {
+			this.pos++
+			item, err = this.parseValue(rest, indent)
+		}
+		if err != nil { return nil, err }
+		output = append(output, item)
+	}
+}
+
+func (this *yamlParser) parseMapping(indent int) (interface{}, error) {
+	output := newDataObject()
+	for {
+		if err := this.skipBlank(); err != nil { return nil, err }
+		if this.eof() || this.indent() != indent || isYamlSequenceItem(this.text()) || this.atDocumentMarker() { return output, nil }
+		key, rest, ok := splitYamlKey(this.text())
+		if !ok { return nil, this.errorf("expected a key") }
+		this.pos++
+		var value interface{}
+		var err error
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			if err := this.skipBlank(); err != nil { return nil, err }
+			// A sequence can be at the same indentation as the key it belongs to
+			if !this.eof() && this.indent() == indent && isYamlSequenceItem(this.text()) {
+				value, err = this.parseSequence(indent)
+			} else {
+				value, err = this.parseNode(indent + 1)
+			}
+		} else {
+			value, err = this.parseValue(rest, indent)
+		}
+		if err != nil { return nil, err }
+		output.set(key, value)
+	}
+}
+
+// Parses a value that starts on the line that was just read. Its continuation lines must be
+// indented by more than parentIndent.
+func (this *yamlParser) parseValue(text string, parentIndent int) (interface{}, error) {
+	start := this.pos - 1
+	switch {
+		case strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+			return this.parseBlockScalar(text, parentIndent)
+		case strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{"):
+			// Flow collections can span several lines
+			for yamlFlowDepth(text) > 0 && !this.eof() {
+				text += "\n" + this.lines[this.pos]
+				this.pos++
+			}
+		case strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'"):
+			// So can quoted scalars, whose line breaks are folded into spaces
+			for yamlFlowDepth(text) > 0 && !this.eof() {
+				text += " " + strings.TrimSpace(this.lines[this.pos])
+				this.pos++
+			}
+		case strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "!"):
+			return nil, this.errorAt(start, "anchors, aliases and tags aren't supported")
+		default:
+			text = stripYamlComment(text)
+			// A plain scalar continues on the more indented lines that follow
+			for !this.eof() && strings.TrimSpace(this.lines[this.pos]) != "" && this.indent() > parentIndent && !strings.HasPrefix(this.text(), "#") {
+				if _, _, ok := splitYamlKey(this.text()); ok { return nil, this.errorf("unexpected indentation") }
+				text += " " + stripYamlComment(this.text())
+				this.pos++
+			}
+			return resolveYamlScalar(text), nil
+	}
+	parser := yamlFlowParser{ text, 0 }
+	output, err := parser.parseValue()
+	if err != nil { return nil, this.errorAt(start, err.Error()) }
+	parser.skipSpace()
+	if parser.pos < len(parser.s) { return nil, this.errorAt(start, "unexpected \"" + parser.s[parser.pos:] + "\"") }
+	return output, nil
+}
+
+// Parses a literal ("|") or folded (">") block scalar, whose header can have a chomping
+// indicator ("-" to strip the final line break, "+" to keep the trailing empty lines)
+// and an indentation indicator.
+func (this *yamlParser) parseBlockScalar(header string, parentIndent int) (interface{}, error) {
+	header = stripYamlComment(header)
+	chomping := byte(0)
+	indent := -1
+	for _, c := range []byte(header[1:]) {
+		switch {
+			case c == '-' || c == '+': chomping = c
+			case c >= '1' && c <= '9':
+				indent = int(c - '0')
+				if parentIndent > 0 { indent += parentIndent }
+			default: return nil, this.errorf("invalid block scalar header \"" + header + "\"")
+		}
+	}
+
+	var lines []string
+	for ; !this.eof(); this.pos++ {
+		line := strings.TrimRight(this.lines[this.pos], "\r")
+		if strings.TrimSpace(line) == "" {
+			if indent >= 0 && len(line) > indent {
+				lines = append(lines, line[indent:])
+			} else {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		if indent < 0 {
+			indent = this.indent()
+			if indent <= parentIndent { break }
+		}
+		if len(line) - len(strings.TrimLeft(line, " ")) < indent { break }
+		lines = append(lines, line[indent:])
+	}
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines) - 1] == "" {
+		lines = lines[:len(lines) - 1]
+		trailing++
+	}
+
+	var output string
+	if header[0] == '|' {
+		output = strings.Join(lines, "\n")
+	} else {
+		// Line breaks between lines are folded into spaces, except around empty and more
+		// indented lines
+		breaks := 0
+		moreIndented := false
+		for i, line := range lines {
+			if line == "" {
+				breaks++
+				continue
+			}
+			indented := line[0] == ' ' || line[0] == '\t'
+			switch {
+				case i == breaks: output += strings.Repeat("\n", breaks)
+				case breaks > 0 && (moreIndented || indented): output += strings.Repeat("\n", breaks + 1)
+				case breaks > 0: output += strings.Repeat("\n", breaks)
+				case moreIndented || indented: output += "\n"
+				default: output += " "
+			}
+			output += line
+			breaks = 0
+			moreIndented = indented
+		}
+	}
+	if len(lines) > 0 && chomping != '-' { output += "\n" }
+	if chomping == '+' { output += strings.Repeat("\n", trailing) }
+	return output, nil
+}
+
+// Splits a "key: value" line, whose key can be quoted
+func splitYamlKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		parser := yamlFlowParser{ text, 0 }
+		key, err := parser.parseQuoted()
+		if err != nil { return "", "", false }
+		rest := strings.TrimLeft(text[parser.pos:], " ")
+		if rest != ":" && !strings.HasPrefix(rest, ": ") { return "", "", false }
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+	if text == "" || strings.ContainsRune("[{#&*!|>%@`", rune(text[0])) { return "", "", false }
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && i > 0 && (text[i - 1] == ' ' || text[i - 1] == '\t') { return "", "", false }
+		if text[i] == ':' && (i + 1 == len(text) || text[i + 1] == ' ' || text[i + 1] == '\t') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i + 1:]), true
+		}
+	}
+	return "", "", false
+}
+
+func stripYamlComment(text string) string {
+	if strings.HasPrefix(text, "#") { return "" }
+	for _, separator := range []string{ " #", "\t#" } {
+		if i := strings.Index(text, separator); i >= 0 { text = text[:i] }
+	}
+	return strings.TrimSpace(text)
+}
+
+// Returns how many brackets and quotes are left open at the end of the text
+func yamlFlowDepth(text string) int {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+			case quote == '"' && c == '\\': i++
+			case quote != 0 && c == quote:
+				if quote == '\'' && i + 1 < len(text) && text[i + 1] == '\'' {
+					i++
+				} else {
+					quote = 0
+				}
+			case quote != 0:
+			case c == '"' || c == '\'': quote = c
+			case c == '[' || c == '{': depth++
+			case c == ']' || c == '}': depth--
+			case c == '#' && (i == 0 || text[i - 1] == ' ' || text[i - 1] == '\t' || text[i - 1] == '\n'):
+				for i < len(text) && text[i] != '\n' {
+					i++
+				}
+		}
+	}
+	if quote != 0 { depth++ }
+	return depth
+}
+
+// Parses scalars and flow collections, eg. "[1, {a: b}]"
+type yamlFlowParser struct {
+	s string
+	pos int
+}
+
+func (this *yamlFlowParser) skipSpace() {
+	for this.pos < len(this.s) {
+		c := this.s[this.pos]
+		if c == '#' && (this.pos == 0 || strings.ContainsRune(" \t\r\n", rune(this.s[this.pos - 1]))) {
+			for this.pos < len(this.s) && this.s[this.pos] != '\n' {
+				this.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' { return }
+		this.pos++
+	}
+}
+
+func (this *yamlFlowParser) peek() byte {
+	if this.pos >= len(this.s) { return 0 }
+	return this.s[this.pos]
+}
+
+// Parses a value, which inside collections ends at a ",", "]" or "}"
+func (this *yamlFlowParser) parseValue() (interface{}, error) {
+	this.skipSpace()
+	switch this.peek() {
+		case '[':
+			this.pos++
+			output := []interface{}{}
+			for {
+				this.skipSpace()
+				if this.peek() == ']' {
+					this.pos++
+					return output, nil
+				}
+				item, err := this.parseValue()
+				if err != nil { return nil, err }
+				output = append(output, item)
+				if err := this.parseSeparator(']'); err != nil { return nil, err }
+			}
+		case '{':
+			this.pos++
+			output := newDataObject()
+			for {
+				this.skipSpace()
+				if this.peek() == '}' {
+					this.pos++
+					return output, nil
+				}
+				var key string
+				var err error
+				if c := this.peek(); c == '"' || c == '\'' {
+					key, err = this.parseQuoted()
+				} else {
+					key = this.parsePlain(true)
+				}
+				if err != nil { return nil, err }
+				this.skipSpace()
+				var value interface{}
+				if this.peek() == ':' {
+					this.pos++
+					this.skipSpace()
+					if c := this.peek(); c != ',' && c != '}' {
+						value, err = this.parseValue()
+						if err != nil { return nil, err }
+					}
+				}
+				output.set(key, value)
+				if err := this.parseSeparator('}'); err != nil { return nil, err }
+			}
+		case '"', '\'':
+			return this.parseQuoted()
+		case '&', '*', '!':
+			return nil, errors.New("anchors, aliases and tags aren't supported")
+		case 0:
+			return nil, nil
+	}
+	return resolveYamlScalar(this.parsePlain(false)), nil
+}
+
+func (this *yamlFlowParser) parseSeparator(end byte) error {
+	this.skipSpace()
+	switch this.peek() {
+		case ',':
+			this.pos++
+			return nil
+		case end:
+			return nil
+		case 0:
+			return errors.New("missing \"" + string(end) + "\"")
+	}
+	return errors.New("expected \",\" or \"" + string(end) + "\" at \"" + this.s[this.pos:] + "\"")
+}
+
+// Reads a plain scalar, which in a key also ends at a ":"
+func (this *yamlFlowParser) parsePlain(key bool) string {
+	start := this.pos
+	for this.pos < len(this.s) {
+		c := this.s[this.pos]
+		if c == ',' || c == ']' || c == '}' || c == '\n' { break }
+		if c == '#' && this.pos > start && (this.s[this.pos - 1] == ' ' || this.s[this.pos - 1] == '\t') { break }
+		if c == ':' && (key || this.pos + 1 == len(this.s) || strings.ContainsRune(" \t\n,]}", rune(this.s[this.pos + 1]))) { break }
+		this.pos++
+	}
+	return strings.TrimSpace(this.s[start:this.pos])
+}
+
+func (this *yamlFlowParser) parseQuoted() (string, error) {
+	quote := this.s[this.pos]
+	this.pos++
+	var output strings.Builder
+	for this.pos < len(this.s) {
+		c := this.s[this.pos]
+		this.pos++
+		switch {
+			case c == quote && quote == '\'' && this.peek() == '\'':
+				output.WriteByte('\'')
+				this.pos++
+			case c == quote:
+				return output.String(), nil
+			case c == '\\' && quote == '"':
+				if this.pos >= len(this.s) { break }
+				e := this.s[this.pos]
+				this.pos++
+				escapes := map[byte]string{
+					'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+					'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085",
+					'_': " ", 'L': " ", 'P': " ",
+				}
+				if s, ok := escapes[e]; ok {
+					output.WriteString(s)
+					continue
+				}
+				size := map[byte]int{ 'x': 2, 'u': 4, 'U': 8 }[e]
+				if size == 0 || this.pos + size > len(this.s) { return "", errors.New("invalid escape sequence \"\\" + string(e) + "\"") }
+				code, err := strconv.ParseUint(this.s[this.pos:this.pos + size], 16, 32)
+				if err != nil { return "", errors.New("invalid escape sequence \"\\" + this.s[this.pos - 1:this.pos + size] + "\"") }
+				output.WriteRune(rune(code))
+				this.pos += size
+			default:
+				output.WriteByte(c)
+		}
+	}
+	return "", errors.New("unterminated quoted string")
+}
+
+// Returns the value of a plain scalar, following the core schema of YAML 1.2
+func resolveYamlScalar(s string) interface{} {
+	switch s {
+		case "", "~", "null", "Null", "NULL": return nil
+		case "true", "True", "TRUE": return true
+		case "false", "False", "FALSE": return false
+		case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF": return math.Inf(1)
+		case "-.inf", "-.Inf", "-.INF": return math.Inf(-1)
+		case ".nan", ".NaN", ".NAN": return math.NaN()
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") {
+		base := 16
+		if s[1] == 'o' { base = 8 }
+		if n, err := strconv.ParseInt(s[2:], base, 64); err == nil { return n }
+	}
+	if strings.Trim(s, "+-0123456789") == "" && strings.LastIndexAny(s, "+-") <= 0 {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err == nil { return n }
+		// Integers too large for 64 bits are kept as they are written
+		if s != "+" && s != "-" { return json.Number(strings.TrimLeft(strings.TrimPrefix(s, "+"), "0")) }
+	}
+	if strings.Trim(s, "+-0123456789.eE") == "" && strings.ContainsAny(s, "0123456789") {
+		if n, err := strconv.ParseFloat(s, 64); err == nil { return n }
+	}
+	return s
+}
+
+func (this *Conversions) readYaml(input io.Reader) (interface{}, error) {
+	data, err := ioutil.ReadAll(input)
+	if err != nil { return nil, err }
+	s := strings.TrimPrefix(string(data), "\ufeff")
+	parser := yamlParser{ strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n"), 0 }
+	return parser.parse()
+}
+
+// Writing
+
+// Whether a string has to be quoted so that it isn't read as another value or as YAML syntax.
+// The YAML 1.1 bools, such as "yes" and "off", are quoted for the parsers that still read them.
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.ContainsRune("-?:,[]{}#&*!|>'\"%@` \t", rune(s[0])) { return true }
+	if _, ok := resolveYamlScalar(s).(string); !ok { return true }
+	switch strings.ToLower(s) {
+		case "y", "n", "yes", "no", "on", "off": return true
+	}
+	// Times such as "12:30" are sexagesimal numbers in YAML 1.1
+	if strings.Trim(s, "0123456789:._") == "" { return true }
+	if strings.HasSuffix(s, " ") || strings.HasSuffix(s, ":") || strings.Contains(s, ": ") || strings.Contains(s, " #") { return true }
+	for _, r := range s {
+		if !unicode.IsPrint(r) { return true }
+	}
+	return false
+}
+
+// Formats a scalar or an empty collection. Strings of several lines are written as literal
+// block scalars, whose lines are indented by indent.
+func formatYamlScalar(value interface{}, indent string) (string, bool, error) {
+	switch v := value.(type) {
+		case string:
+			body := strings.TrimRight(v, "\n")
+			printable := !strings.HasPrefix(v, " ") && !strings.HasPrefix(v, "\t")
+			for _, r := range v {
+				if !unicode.IsPrint(r) && r != '\n' && r != '\t' { printable = false }
+			}
+			if strings.Contains(body, "\n") && printable {
+				header := "|-"
+				if trailing := len(v) - len(body); trailing == 1 {
+					header = "|"
+				} else if trailing > 1 {
+					header = "|+"
+				}
+				output := header
+				for _, line := range strings.Split(strings.TrimSuffix(v, "\n"), "\n") {
+					if line == "" {
+						output += "\n"
+					} else {
+						output += "\n" + indent + line
+					}
+				}
+				return output, true, nil
+			}
+			if yamlNeedsQuotes(v) { return quoteJsonString(v), true, nil }
+			return v, true, nil
+		case float64:
+			switch {
+				case math.IsInf(v, 1): return ".inf", true, nil
+				case math.IsInf(v, -1): return "-.inf", true, nil
+				case math.IsNaN(v): return ".nan", true, nil
+			}
+		case []interface{}:
+			if len(v) > 0 { return "", false, nil }
+			return "[]", true, nil
+		case *dataObject:
+			if len(v.keys) > 0 { return "", false, nil }
+			return "{}", true, nil
+	}
+	s, err := formatDataScalar(value)
+	return s, true, err
+}
+
+// Writes a collection as a block, each line starting with indent
+func formatYamlBlock(output *strings.Builder, value interface{}, indent string) error {
+	switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				s, ok, err := formatYamlScalar(item, indent + "  ")
+				if err != nil { return err }
+				if ok {
+					output.WriteString(indent + "- " + s + "\n")
+					continue
+				}
+				// The first line of a nested collection goes after the dash
+				var block strings.Builder
+				if err := formatYamlBlock(&block, item, indent + "  "); err != nil { return err }
+				output.WriteString(indent + "- " + strings.TrimPrefix(block.String(), indent + "  "))
+			}
+		case *dataObject:
+			for _, key := range v.keys {
+				name := key
+				if yamlNeedsQuotes(key) { name = quoteJsonString(key) }
+				s, ok, err := formatYamlScalar(v.values[key], indent + "  ")
+				if err != nil { return err }
+				if ok {
+					output.WriteString(indent + name + ": " + s + "\n")
+					continue
+				}
+				output.WriteString(indent + name + ":\n")
+				if err := formatYamlBlock(output, v.values[key], indent + "  "); err != nil { return err }
+			}
+	}
+	return nil
+}
+
+func (this *Conversions) writeYaml(output io.Writer, value interface{}) error {
+	var s strings.Builder
+	scalar, ok, err := formatYamlScalar(value, "  ")
+	if err != nil { return err }
+	if ok {
+		s.WriteString(scalar + "\n")
+	} else if err := formatYamlBlock(&s, value, ""); err != nil {
+		return err
+	}
+	_, err = io.WriteString(output, s.String())
+	return err
+}
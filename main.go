@@ -6,19 +6,102 @@ import (
 	"flag"
 	"./conversions"
 	"strings"
+	"strconv"
 	"errors"
 	"os"
 	"fmt"
+	"io"
+	"io/ioutil"
 )
 
-func parseConversionCommand(cmd string) (string, string, error) {
+// Unit names can themselves contain a "2" (eg. rfc1123), so every possible split is
+// tried until one matches a known conversion.
+func parseConversionCommand(cmd string, conv *conversions.Conversions) (string, string, error) {
 	tokens := strings.Split(cmd, "2")
+	if len(tokens) < 2 {
+		return "", "", errors.New("Not a conversion command: \"" + cmd + "\"")
+	}
+	for i := 1; i < len(tokens); i++ {
+		from := strings.Join(tokens[0:i], "2")
+		to := strings.Join(tokens[i:], "2")
+		if conv.HasConversion(from, to) {
+			return from, to, nil
+		}
+		if conv.IsOneWay(to, from) {
+			return "", "", errors.New("The conversion from \"" + to + "\" to \"" + from + "\" is one-way and can't be reversed.")
+		}
+	}
 	if len(tokens) != 2 {
 		return "", "", errors.New("Not a conversion command: \"" + cmd + "\"")
 	}
 	return tokens[0], tokens[1], nil
 }
 
+// Whether an argument has the form of a flag, eg. "--lang" or "--lang=fr", as opposed to
+// a value that starts with dashes, such as the Morse code "--- ... ---"
+func looksLikeFlag(arg string) bool {
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if equalPos := strings.Index(name, "="); equalPos >= 0 {
+		name = name[0:equalPos]
+	}
+	if name == "" || name[0] < 'a' || name[0] > 'z' { return false }
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && c != '-' { return false }
+	}
+	return true
+}
+
+// Parses the flags, which unlike with flag.Parse() can also appear after the command,
+// and returns the remaining arguments. Values that start with a single dash, such as
+// negative numbers, are only treated as flags if they match one, and those that start
+// with two dashes only if they have the form of a flag.
+func parseArgs(arguments []string) ([]string, error) {
+	var output []string
+	for i := 0; i < len(arguments); i++ {
+		arg := arguments[i]
+		if arg == "--" {
+			return append(output, arguments[i+1:]...), nil
+		}
+		_, numErr := strconv.ParseFloat(arg, 64)
+		if len(arg) < 2 || arg[0] != '-' || numErr == nil || !looksLikeFlag(arg) {
+			output = append(output, arg)
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		hasValue := false
+		if equalPos := strings.Index(name, "="); equalPos >= 0 {
+			value = name[equalPos+1:]
+			name = name[0:equalPos]
+			hasValue = true
+		}
+		f := flag.Lookup(name)
+		if f == nil && !strings.HasPrefix(arg, "--") {
+			output = append(output, arg)
+			continue
+		}
+		if f == nil {
+			return nil, errors.New("Unknown flag: \"" + arg + "\"")
+		}
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+			if !hasValue {
+				value = "true"
+			}
+		} else if !hasValue {
+			if i + 1 >= len(arguments) {
+				return nil, errors.New("Missing value for flag \"" + arg + "\"")
+			}
+			i++
+			value = arguments[i]
+		}
+		err := flag.Set(name, value)
+		if err != nil {
+			return nil, errors.New("Invalid value for flag \"" + arg + "\": " + fmt.Sprint(err))
+		}
+	}
+	return output, nil
+}
+
 func printFlags() {
 	longestName := 0
 	flag.VisitAll(func(f *flag.Flag) {
@@ -39,21 +122,120 @@ func printFlags() {
 }
 
 func printUsage() {
-	fmt.Println("Usage: aconv [flags] <command> [<value>]")
+	fmt.Println("Usage: aconv [flags] <command> [<value>] [flags]")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("   list          Lists all the possible conversions.")
-	fmt.Println("   <from>2<to>   Converts from <from> to <to>. eg. hex2bin, dec2oct, eur2usd, etc.")
+	fmt.Println("   <from>2<to>   Converts from <from> to <to>. eg. hex2bin, dec2oct, eur2usd, etc. The value is read from stdin if omitted or \"-\".")
+	fmt.Println("   default       Lists the saved option defaults, or saves one: default <option> <value>. eg. default dpi 326")
+	fmt.Println("   ingredient    Lists cooking ingredients, or sets a density: ingredient <name> <g/ml>.")
+	fmt.Println("   tz            Converts a wall-clock time between time zones: tz <time> --from <zone> --to <zones>.")
+	fmt.Println("   uuid          Generates UUIDs: uuid [v4|v7]. eg. uuid v7 --count 5")
+	fmt.Println("   contrast      Displays the WCAG contrast ratio between two colors: contrast <color1> <color2>.")
+	fmt.Println("   deltae        Displays the color difference between two colors: deltae <color1> <color2>.")
+	fmt.Println("   unit          Converts between unit expressions: unit <from> <to> <value>. eg. unit m/s km/h 10")
 	fmt.Println("   help          Displays this help page.")
 	fmt.Println("")
 	fmt.Println("Flags:")
 	printFlags()
 	fmt.Println("")
 	fmt.Println("Examples:")
-	fmt.Println("   aconv bin2hex 1100110010   # Convert binary to hexadecimal")
-	fmt.Println("   aconv hex2dec ff5c         # Convert hexadecimal to decimal")
-	fmt.Println("   aconv eur2usd 10           # Convert Euros to US Dollars")
-	fmt.Println("   aconv aud2jpy 5000         # Convert Australian Dollars to Japanese Yens")
+	fmt.Println("   aconv bin2hex 1100110010                 # Convert binary to hexadecimal")
+	fmt.Println("   aconv hex2dec ff5c                       # Convert hexadecimal to decimal")
+	fmt.Println("   aconv eur2usd 10                         # Convert Euros to US Dollars")
+	fmt.Println("   aconv aud2jpy 5000                       # Convert Australian Dollars to Japanese Yens")
+	fmt.Println("   aconv c2f 21.5                           # Convert Celsius to Fahrenheit")
+	fmt.Println("   aconv --delta c2f 10                     # Convert a temperature difference")
+	fmt.Println("   aconv --ingredient flour cup2g 2         # Convert 2 cups of flour to grams")
+	fmt.Println("   aconv ingredient spelt 0.48              # Define the density of an ingredient")
+	fmt.Println("   aconv mpg2l100km 35                      # Convert fuel economy")
+	fmt.Println("   aconv minkm2kmh 5:30                     # Convert a running pace to a speed")
+	fmt.Println("   aconv --dpi 326 pt2px 12                 # Convert typographic points to pixels on a given screen")
+	fmt.Println("   aconv --root-font-size 10 rem2px 2.4")
+	fmt.Println("   aconv epoch2iso 1760700000               # Convert a Unix timestamp to an ISO 8601 date")
+	fmt.Println("   aconv iso2epochms 2026-10-17T12:00:00Z")
+	fmt.Println("   aconv go2isodur 1h30m                    # Convert a Go duration to an ISO 8601 duration")
+	fmt.Println("   aconv text2clock \"1 day 3 hours\"")
+	fmt.Println("   aconv isodur2day P1M --anchor 2026-02-01")
+	fmt.Println("   aconv gregorian2isoweek 2026-10-17       # Convert a date to an ISO week date")
+	fmt.Println("   aconv gregorian2hebrew 2026-09-12")
+	fmt.Println("   aconv ulid2iso 01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	fmt.Println("   aconv uuid2fields 1ec9414c-232a-6b00-b3c8-9f6bdeced846")
+	fmt.Println("   aconv uuid2uuid58 1ec9414c-232a-6b00-b3c8-9f6bdeced846")
+	fmt.Println("   aconv uuid v7 --seed 42 --uuid-time 2026-10-17T12:00:00Z --count 3")
+	fmt.Println("   aconv tz \"2026-10-20 09:00\" --from Europe/Paris --to America/New_York,Asia/Tokyo")
+	fmt.Println("   aconv hex2hsl \"#3a7bd5\"                  # Convert a hexadecimal color to HSL")
+	fmt.Println("   aconv rgb2name 58,123,213                # Find the nearest CSS color name")
+	fmt.Println("   aconv contrast \"#777\" white              # Check the contrast of text on a background")
+	fmt.Println("   aconv ip2dec 10.0.0.1                    # Convert an IP address to an integer")
+	fmt.Println("   aconv cidr2range 192.168.0.0/22")
+	fmt.Println("   aconv range2cidr 10.0.0.5-10.0.0.20      # Split a range into CIDR blocks")
+	fmt.Println("   aconv ip2ipfull 2001:db8::1              # Expand an IPv6 address")
+	fmt.Println("   aconv mac2maccisco 00:1a:2b:3c:4d:5e     # Convert a MAC address to the Cisco format")
+	fmt.Println("   aconv mac2linklocal 00:1a:2b:3c:4d:5e")
+	fmt.Println("   aconv oct2perm 4755                      # Convert an octal mode to a symbolic one")
+	fmt.Println("   aconv chmod2oct u+x,go-w --mode 0666     # Apply a chmod expression to a mode")
+	fmt.Println("   aconv umask2perm 027")
+	fmt.Println("   aconv text2url \"a&b=c d\"                 # Percent-encode a URL component")
+	fmt.Println("   echo '<p>Hi</p>' | aconv text2html       # Escape HTML from stdin")
+	fmt.Println("   aconv json2text '\"line\\nbreak\"'")
+	fmt.Println("   aconv camel2snake HTTPServerError        # Convert an identifier to snake_case")
+	fmt.Println("   cut -d, -f1 fields.csv | aconv snake2pascal")
+	fmt.Println("   aconv unicode2punycode bücher.example    # Convert an internationalized domain name")
+	fmt.Println("   aconv punycode2unicode https://xn--bcher-kva.example/path")
+	fmt.Println("   aconv cp12522utf8 --input export.csv --output export-utf8.csv")
+	fmt.Println("   cat legacy.txt | aconv shiftjis2utf8 --charset-errors replace")
+	fmt.Println("   aconv text2morse \"SOS <AR>\"              # Encode text in Morse code, with a prosign")
+	fmt.Println("   aconv morse2text \"...|---|...\" --morse-letter \"|\"")
+	fmt.Println("   aconv text2nato XK7-42B                  # Spell a code with the NATO phonetic alphabet")
+	fmt.Println("   aconv text2braille \"Room 12\"             # Transcribe text in grade 1 Braille")
+	fmt.Println("   aconv cyrillic2iso9 \"Щука\"               # Transliterate Cyrillic with ISO 9")
+	fmt.Println("   aconv cyrillic2bgn \"Юрий Гагарин\"")
+	fmt.Println("   aconv greek2elot Θεσσαλονίκη")
+	fmt.Println("   aconv text2slug \"Crème brûlée à Zürich\"  # Make a URL slug")
+	fmt.Println("   aconv dec2words 1234.56 --lang en        # Write a number in words")
+	fmt.Println("   aconv eur2words 1234.56 --lang fr        # Write an amount in words, as on a cheque")
+	fmt.Println("   aconv dec2ordinal 21 --lang de")
+	fmt.Println("   aconv words2dec \"doscientos mil treinta y uno\" --lang es")
+	fmt.Println("   aconv dec2zh 10050                       # Write a number in Chinese numerals")
+	fmt.Println("   aconv dec2zhfin 12345.67")
+	fmt.Println("   aconv ja2dec 二千二十六")
+	fmt.Println("   aconv json2yaml --input config.json      # Convert a JSON document to YAML")
+	fmt.Println("   aconv toml2json --input Cargo.toml --output cargo.json")
+	fmt.Println("   aconv csv2json --input people.csv        # Convert CSV rows to objects with typed values")
+	fmt.Println("   curl -s https://example.com/api | aconv json2csv > rows.csv")
+	fmt.Println("   aconv xml2json --input feed.xml")
+	fmt.Println("   aconv text2sha256 \"hello world\"          # Hash text with SHA-256")
+	fmt.Println("   aconv file2blake2b release.tar.gz        # Hash a file, read progressively")
+	fmt.Println("   aconv file2sha256 --input image.iso --check 3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b")
+	fmt.Println("   echo -n hello | aconv text2xxhash --digest-format base64")
+	fmt.Println("   aconv unit kg*m/s^2 N 5                  # Convert a compound unit expression to Newtons")
+	fmt.Println("   aconv unit g/cm^3 kg/m^3 1               # Convert a density")
+}
+
+// Whether the output is a terminal that can display 24-bit colors
+func supportsTrueColor() bool {
+	colorTerm := os.Getenv("COLORTERM")
+	if colorTerm != "truecolor" && colorTerm != "24bit" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode() & os.ModeCharDevice != 0
+}
+
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode() & os.ModeCharDevice == 0
+}
+
+// Reads all of stdin, without the final line break
+func readStdin() (string, error) {
+	input, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(input), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
 }
 
 func createFormat(formatType string) (string, error) {
@@ -77,22 +259,98 @@ func exitWithError(message string) {
 	os.Exit(1)
 }
 
+// A digest mismatch is the expected way for --check to fail, so only the mismatch is printed
+func exitWithConversionError(err error) {
+	if _, ok := err.(conversions.DigestMismatchError); ok {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	exitWithError("Could not convert input: " + fmt.Sprint(err))
+}
+
 func main() {
 	var fFormat string
 	var fReverse bool
 	var fHelp bool
+	var fFrom string
+	var fTo string
+	var fCount int
+	var fInput string
+	var fOutput string
+	var fCheck string
+	var fSeed string
+	var fUuidTime string
 	flag.StringVar(&fFormat, "format", "full", "Output format - either \"simple\", \"withUnit\" or \"full\".")
 	flag.BoolVar(&fReverse, "reverse", false, "Reverse the conversion. eg. hex2bin becomes bin2hex, etc.")
+	
+	// Flags that are passed on to the conversions as options. When not specified, the defaults
+	// saved with the "default" command are used.
+	optionFlags := []string{"delta", "ingredient", "dpi", "root-font-size", "font-scale", "tz", "layout", "dst", "anchor", "snowflake-epoch", "delta-e", "byte-order", "mode", "charset-errors", "morse-letter", "morse-word", "slug-separator", "lang", "csv-header", "csv-separator", "digest-format"}
+	flag.Bool("delta", false, "Convert temperatures as differences rather than absolute values. eg. a delta of 10 c is 18 f.")
+	flag.String("ingredient", "", "Ingredient used to convert between cooking volumes and masses. eg. flour, sugar, butter.")
+	flag.Float64("dpi", 96, "Screen resolution used for typography conversions, in dots per inch.")
+	flag.Float64("root-font-size", 16, "Root font size used for em and rem conversions, in pixels.")
+	flag.Float64("font-scale", 1, "Android font scale used for sp conversions.")
+	flag.String("tz", "UTC", "Time zone of dates, either \"UTC\", \"Local\" or an IANA name such as \"Europe/Paris\".")
+	flag.String("layout", "", "Go reference layout used by the \"custom\" time unit. eg. \"02/01/2006 15:04\".")
+	flag.String("anchor", "", "Date from which durations in months and years are counted. eg. 2026-01-31")
+	flag.String("snowflake-epoch", "twitter", "Epoch of snowflake IDs, either \"twitter\", \"discord\" or a Unix time in milliseconds.")
+	flag.String("mode", "0644", "Octal mode to which chmod expressions are applied. eg. 0755")
+	flag.String("byte-order", "big", "Byte order of IP addresses as integers, either \"big\" (network order) or \"little\".")
+	flag.String("morse-letter", " ", "Separator between the letters of Morse code.")
+	flag.String("morse-word", " / ", "Separator between the words of Morse code.")
+	flag.String("lang", "en", "Language of numbers in words, either \"en\", \"fr\", \"de\" or \"es\".")
+	flag.String("slug-separator", "-", "Separator between the words of slugs. eg. \"_\" for usernames")
+	flag.String("csv-header", "auto", "Whether the first row of CSV has the column names, either \"yes\", \"no\" or \"auto\" to detect it.")
+	flag.String("csv-separator", "auto", "Separator between the fields of CSV, either a character, \"tab\" or \"auto\" to detect it.")
+	flag.String("digest-format", "hex", "Encoding of hash digests, either \"hex\" or \"base64\".")
+	flag.String("charset-errors", "strict", "How charset conversions handle invalid input and characters that can't be encoded, either \"strict\" to fail or \"replace\".")
+	flag.String("delta-e", "2000", "CIE color difference formula used for nearest color names and the deltae command, either \"76\", \"94\" or \"2000\".")
+	flag.String("dst", "earlier", "How the tz command resolves a time that is ambiguous (\"earlier\" or \"later\") or that does not exist (\"error\" to fail).")
+	
+	flag.StringVar(&fFrom, "from", "Local", "Time zone of the input of the tz command.")
+	flag.StringVar(&fTo, "to", "", "Comma-separated time zones to convert to with the tz command. eg. America/New_York,Asia/Tokyo")
+	flag.StringVar(&fInput, "input", "", "File read by streaming conversions, such as charset, data and hash conversions, instead of stdin.")
+	flag.StringVar(&fOutput, "output", "", "File written by streaming conversions instead of stdout.")
+	flag.StringVar(&fCheck, "check", "", "Expected digest that hash conversions compare with the result, failing if it differs.")
+	flag.IntVar(&fCount, "count", 1, "Number of UUIDs generated by the uuid command.")
+	flag.StringVar(&fSeed, "seed", "", "Seed that makes the uuid command generate the same UUIDs every time, eg. for tests. Version 7 UUIDs then have the time of the Unix epoch, unless --uuid-time is given.")
+	flag.StringVar(&fUuidTime, "uuid-time", "", "Time of the version 7 UUIDs generated by the uuid command, instead of the current time. eg. 2026-10-17T12:00:00Z")
 	flag.BoolVar(&fHelp, "help", false, "Displays this help page.") // Defined only to prevent Go from outputting the default help page
-	flag.Parse()
 	
-	args := flag.Args()
+	args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		exitWithError(fmt.Sprint(err))
+	}
+	
+	if fHelp {
+		printUsage()
+		os.Exit(0)
+	}
 	
 	if len(args) < 1 {
 		exitWithError("No command specified.")
 	}
 	
 	conv := conversions.NewConversions()
+	flag.Visit(func(f *flag.Flag) {
+		for _, name := range optionFlags {
+			if f.Name == name {
+				conv.SetOption(name, f.Value.String())
+			}
+		}
+	})
+	// Unlike the options, the expected digest and the UUID seed and time can't be saved as
+	// defaults, as they only make sense for one invocation
+	if fCheck != "" {
+		conv.SetOption("check", fCheck)
+	}
+	if fSeed != "" {
+		conv.SetOption("seed", fSeed)
+	}
+	if fUuidTime != "" {
+		conv.SetOption("uuid-time", fUuidTime)
+	}
 	
 	command := strings.ToLower(args[0])
 	
@@ -114,27 +372,260 @@ func main() {
 				s += strings.Title(conv.NiceCategoryName(categoryName)) + "\n"
 				unitNames := conv.UnitNames(categoryName)
 				for _, unitName := range unitNames {
-					s += "   " + unitName + "   " + conv.NiceUnitName(categoryName, unitName) + "\n"
+					s += "   " + unitName + "   " + conv.NiceUnitName(categoryName, unitName)
+					if conv.IsOneWayUnit(categoryName, unitName) {
+						s += " (one-way)"
+					}
+					s += "\n"
 				}
 			}
+			s += "\nUnit expressions (combine with *, / and ^, eg. kg*m/s^2)\n"
+			for _, unitName := range conv.ExpressionUnitNames() {
+				s += "   " + unitName + "   " + conv.NiceExpressionUnitName(unitName) + "\n"
+			}
 			fmt.Println(s)
 			os.Exit(0)
 			
-		default: 
+		case "ingredient":
 		
-			if len(args) < 2 {
+			if len(args) == 1 {
+				for _, name := range conv.IngredientNames() {
+					density, _ := conv.IngredientDensity(name)
+					fmt.Printf("   %s   %g g/ml\n", name, density)
+				}
+				os.Exit(0)
+			}
+			
+			name := args[1]
+			if len(args) >= 3 {
+				density, err := strconv.ParseFloat(args[2], 64)
+				if err != nil {
+					exitWithError("Invalid density: " + fmt.Sprint(err))
+				}
+				err = conv.SetIngredientDensity(name, density)
+				if err != nil {
+					exitWithError("Could not save ingredient: " + fmt.Sprint(err))
+				}
+			}
+			density, err := conv.IngredientDensity(name)
+			if err != nil {
+				exitWithError(fmt.Sprint(err))
+			}
+			fmt.Printf("%s: %g g/ml\n", name, density)
+			os.Exit(0)
+			
+		case "default":
+		
+			if len(args) == 1 {
+				options := conv.DefaultOptions()
+				for _, name := range optionFlags {
+					if value, exists := options[name]; exists {
+						fmt.Printf("   %s   %s\n", name, value)
+					}
+				}
+				os.Exit(0)
+			}
+			
+			name := strings.ToLower(args[1])
+			f := flag.Lookup(name)
+			isOption := false
+			for _, n := range optionFlags {
+				if n == name { isOption = true }
+			}
+			if f == nil || !isOption {
+				exitWithError("Unknown option: \"" + name + "\"")
+			}
+			if len(args) < 3 {
 				exitWithError("No value specified.")
 			}
+			// Validate the value using the flag parser
+			err := f.Value.Set(args[2])
+			if err != nil {
+				exitWithError("Invalid value for option \"" + name + "\": " + fmt.Sprint(err))
+			}
+			err = conv.SetDefaultOption(name, f.Value.String())
+			if err != nil {
+				exitWithError("Could not save default: " + fmt.Sprint(err))
+			}
+			fmt.Printf("%s: %s\n", name, f.Value.String())
+			os.Exit(0)
+			
+		case "tz":
 		
-			fromUnit, toUnit, err := parseConversionCommand(args[0])
+			if len(args) < 2 {
+				exitWithError("No time specified.")
+			}
+			
+			fromZone := fFrom
+			var toZones []string
+			for _, zone := range strings.Split(fTo, ",") {
+				if strings.TrimSpace(zone) != "" { toZones = append(toZones, zone) }
+			}
+			if fReverse {
+				if len(toZones) != 1 {
+					exitWithError("--reverse requires a single --to time zone.")
+				}
+				fromZone = toZones[0]
+				toZones = []string{fFrom}
+			}
+			
+			format, err := createFormat(fFormat)
 			if err != nil {
 				exitWithError(fmt.Sprint(err))
 			}
+			result, err := conv.ConvertTimeZoneFormat(format, args[1], fromZone, toZones)
+			if err != nil {
+				exitWithError("Could not convert input: " + fmt.Sprint(err))
+			}
+			
+			fmt.Println(result)
+			os.Exit(0)
+			
+		case "uuid":
+		
+			version := 4
+			if len(args) >= 2 {
+				v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(args[1]), "v"))
+				if err != nil {
+					exitWithError("Invalid UUID version: \"" + args[1] + "\"")
+				}
+				version = v
+			}
+			
+			uuids, err := conv.GenerateUuids(version, fCount)
+			if err != nil {
+				exitWithError("Could not generate UUIDs: " + fmt.Sprint(err))
+			}
+			fmt.Println(strings.Join(uuids, "\n"))
+			os.Exit(0)
+			
+		case "contrast", "deltae":
+		
+			if len(args) < 3 {
+				exitWithError("Usage: aconv " + command + " <color1> <color2>")
+			}
+			
+			var result string
+			if command == "contrast" {
+				result, err = conv.ColorContrast(args[1], args[2])
+			} else {
+				result, err = conv.ColorDifference(args[1], args[2])
+			}
+			if err != nil {
+				exitWithError("Could not compare colors: " + fmt.Sprint(err))
+			}
+			fmt.Println(result)
+			os.Exit(0)
+			
+		case "unit":
+		
+			if len(args) < 4 {
+				exitWithError("Usage: aconv unit <from> <to> <value>")
+			}
+			
+			fromUnit := args[1]
+			toUnit := args[2]
 			if fReverse {
 				temp := fromUnit
 				fromUnit = toUnit
 				toUnit = temp
 			}
+			
+			format, err := createFormat(fFormat)
+			if err != nil {
+				exitWithError(fmt.Sprint(err))
+			}
+			result, err := conv.ConvertExpressionFormat(format, fromUnit, toUnit, args[3])
+			if err != nil {
+				exitWithError("Could not convert input: " + fmt.Sprint(err))
+			}
+			
+			fmt.Println(result)
+			os.Exit(0)
+			
+		default: 
+		
+			fromUnit, toUnit, err := parseConversionCommand(args[0], conv)
+			if err != nil {
+				exitWithError(fmt.Sprint(err))
+			}
+			if fReverse {
+				if conv.IsOneWay(fromUnit, toUnit) {
+					exitWithError("The conversion from \"" + fromUnit + "\" to \"" + toUnit + "\" is one-way and can't be reversed.")
+				}
+				temp := fromUnit
+				fromUnit = toUnit
+				toUnit = temp
+			}
+			
+			// --input and --output only apply to streaming conversions, and the value then
+			// comes from the input file
+			if fInput != "" || fOutput != "" {
+				if !conv.HasConversion(fromUnit, toUnit) {
+					exitWithError("Unsupported conversion: \"" + fromUnit + "\" to \"" + toUnit + "\"")
+				}
+				if !conv.HasStreamConversion(fromUnit, toUnit) {
+					exitWithError("--input and --output can only be used with streaming conversions, such as charset, data and hash conversions.")
+				}
+				if fInput != "" && len(args) >= 2 {
+					exitWithError("A value can't be specified with --input.")
+				}
+				if fOutput != "" && len(args) >= 2 && args[1] != "-" {
+					exitWithError("--output requires the input to be read from --input or stdin.")
+				}
+			}
+			
+			// Streaming conversions read files or stdin progressively, and their output is
+			// written as it is, without any formatting.
+			if conv.HasStreamConversion(fromUnit, toUnit) && (fInput != "" || len(args) < 2 || args[1] == "-") {
+				var input io.Reader = os.Stdin
+				var output io.Writer = os.Stdout
+				if fInput != "" {
+					file, err := os.Open(fInput)
+					if err != nil {
+						exitWithError("Could not open input: " + fmt.Sprint(err))
+					}
+					defer file.Close()
+					input = file
+				} else if len(args) < 2 && !stdinIsPiped() {
+					exitWithError("No value specified.")
+				}
+				if fOutput != "" {
+					file, err := os.Create(fOutput)
+					if err != nil {
+						exitWithError("Could not create output: " + fmt.Sprint(err))
+					}
+					defer file.Close()
+					output = file
+				}
+				err = conv.ConvertStream(fromUnit, toUnit, input, output)
+				if _, ok := err.(conversions.DigestMismatchError); ok {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				} else if err != nil {
+					fmt.Fprintln(os.Stderr, "Could not convert input: " + fmt.Sprint(err))
+					os.Exit(1)
+				}
+				return
+			}
+			
+			// The value is read from stdin when it is "-" or when it is missing and stdin
+			// is not a terminal. The result is then output on its own, so that it can be piped.
+			if len(args) < 2 || args[1] == "-" {
+				if len(args) < 2 && !stdinIsPiped() {
+					exitWithError("No value specified.")
+				}
+				value, err := readStdin()
+				if err != nil {
+					exitWithError("Could not read input: " + fmt.Sprint(err))
+				}
+				result, err := conv.Convert(fromUnit, toUnit, value)
+				if err != nil {
+					exitWithConversionError(err)
+				}
+				fmt.Println(result)
+				os.Exit(0)
+			}
 			value := args[1]
 			
 			format, err := createFormat(fFormat)
@@ -143,7 +634,14 @@ func main() {
 			}
 			result, err := conv.ConvertFormat(format, fromUnit, toUnit, value)
 			if err != nil {
-				exitWithError("Could not convert input: " + fmt.Sprint(err))
+				exitWithConversionError(err)
+			}
+			
+			if conv.CategoryName(fromUnit, toUnit) == "color" && supportsTrueColor() {
+				r, g, b, err := conv.ColorRgb(fromUnit, value)
+				if err == nil {
+					result = fmt.Sprintf("\x1b[48;2;%d;%d;%dm    \x1b[0m ", r, g, b) + result
+				}
 			}
 			
 			fmt.Println(result)
@@ -3,6 +3,7 @@ package settings
 import (
 	"os"
 	"os/user"
+	"sort"
 	"io/ioutil"
 	"strings"
 	"strconv"
@@ -126,6 +127,16 @@ func (this *Settings) Value(category string, name string, defaultValue string) s
 	return output
 }
 
+func (this *Settings) Names(category string) []string {
+	this.Load()
+	var output []string
+	for name := range this.inner_[category] {
+		output = append(output, name)
+	}
+	sort.Strings(output)
+	return output
+}
+
 func (this *Settings) ValueFloat64(category string, name string, defaultValue float64) float64 {
 	s := this.Value(category, name, "")
 	if s == "" { return defaultValue }